@@ -1,11 +1,24 @@
 package main
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/outscale-dev/docker-machine-driver-outscale/pkg/drivers/outscale"
 
 	"github.com/docker/machine/libmachine/drivers/plugin"
 )
 
 func main() {
+	// docker-machine starts the plugin without arguments, the arguments are
+	// the commands run by the user on a machine
+	if len(os.Args) > 1 {
+		if err := outscale.RunCommand(os.Args[1:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	plugin.RegisterDriver(outscale.NewDriver("", ""))
 }
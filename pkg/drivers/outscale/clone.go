@@ -0,0 +1,126 @@
+package outscale
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/docker/machine/libmachine/drivers"
+	"github.com/docker/machine/libmachine/mcnflag"
+)
+
+// cloneOptions returns the create options of a machine to build another one
+// like it. The private IPs, which belong to the source machine, are left out
+// like the credentials, which come from the flags or the environment.
+func cloneOptions(storePath string, machineName string) (map[string]interface{}, error) {
+	source, err := loadMachine(storePath, machineName)
+	if err != nil {
+		return nil, err
+	}
+
+	options := source.createOptions()
+	delete(options, flagPrivateIp)
+	delete(options, flagSecondaryIps)
+
+	var nics []string
+	for _, nic := range source.ExtraNics {
+		nics = append(nics, withoutNicPrivateIp(nic))
+	}
+	options[flagNics] = nics
+
+	// The zero values are the options unknown to the driver which created the
+	// source machine, their defaults apply
+	for key, value := range options {
+		if reflect.ValueOf(value).IsZero() {
+			delete(options, key)
+		}
+	}
+
+	return options, nil
+}
+
+// withoutNicPrivateIp removes the private IP from a NIC spec
+func withoutNicPrivateIp(spec string) string {
+	var fields []string
+	for _, field := range strings.Split(spec, ",") {
+		if strings.HasPrefix(strings.TrimSpace(field), "private-ip=") {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return strings.Join(fields, ",")
+}
+
+// layeredDriverOptions gives the flags explicitly set, i.e. differing from
+// their default, precedence over a base set of options. A bool option of the
+// base is turned off by its negated flag, the other flags can not be set back
+// to their default this way.
+type layeredDriverOptions struct {
+	flags       drivers.DriverOptions
+	createFlags []mcnflag.Flag
+	base        map[string]interface{}
+}
+
+func newLayeredDriverOptions(flags drivers.DriverOptions, createFlags []mcnflag.Flag, base map[string]interface{}) *layeredDriverOptions {
+	return &layeredDriverOptions{
+		flags:       flags,
+		createFlags: createFlags,
+		base:        base,
+	}
+}
+
+// isExplicit reports whether the flag value differs from its default
+func (o *layeredDriverOptions) isExplicit(key string, value interface{}) bool {
+	for _, flag := range o.createFlags {
+		if flag.String() != key {
+			continue
+		}
+
+		switch value := value.(type) {
+		case bool:
+			return value
+		case []string:
+			return len(value) > 0
+		default:
+			return !reflect.DeepEqual(flag.Default(), value)
+		}
+	}
+	return true
+}
+
+func (o *layeredDriverOptions) String(key string) string {
+	value := o.flags.String(key)
+	if base, ok := o.base[key].(string); ok && !o.isExplicit(key, value) {
+		return base
+	}
+	return value
+}
+
+func (o *layeredDriverOptions) StringSlice(key string) []string {
+	value := o.flags.StringSlice(key)
+	if base, ok := o.base[key].([]string); ok && !o.isExplicit(key, value) {
+		return base
+	}
+	return value
+}
+
+func (o *layeredDriverOptions) Int(key string) int {
+	value := o.flags.Int(key)
+	if base, ok := o.base[key].(int); ok && !o.isExplicit(key, value) {
+		return base
+	}
+	return value
+}
+
+func (o *layeredDriverOptions) Bool(key string) bool {
+	value := o.flags.Bool(key)
+	if base, ok := o.base[key].(bool); ok && !o.isExplicit(key, value) && !o.flags.Bool(negatedFlag(key)) {
+		return base
+	}
+	return value
+}
+
+// negatedFlag returns the flag turning off a bool option of the base, e.g.
+// outscale-no-purge-on-remove
+func negatedFlag(name string) string {
+	return "outscale-no-" + strings.TrimPrefix(name, "outscale-")
+}
@@ -0,0 +1,137 @@
+package outscale
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/machine/libmachine/drivers"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeMachineConfig(t *testing.T, storePath string, name string, driverName string, driver interface{}) {
+	content, err := json.Marshal(map[string]interface{}{
+		"DriverName": driverName,
+		"Driver":     driver,
+	})
+	assert.NoError(t, err)
+
+	machinePath := filepath.Join(storePath, "machines", name)
+	assert.NoError(t, os.MkdirAll(machinePath, 0700))
+	assert.NoError(t, os.WriteFile(filepath.Join(machinePath, "config.json"), content, 0600))
+}
+
+func TestCloneFrom(t *testing.T) {
+	os.Clearenv()
+	storePath := t.TempDir()
+
+	source := NewDriver("source", storePath)
+	assert.NoError(t, source.SetConfigFromFlags(&drivers.CheckDriverOptions{
+		FlagsValues: map[string]interface{}{
+			flagAccessKey:        "SOURCE_ACCESS_KEY",
+			flagSecretKey:        "SOURCE_SECRET_KEY",
+			flagInstanceType:     "tinav5.c4r8p1",
+			flagExtraTagsAll:     []string{"team=ops"},
+			flagRootDiskSize:     50,
+			flagSubnetId:         "subnet-1",
+			flagPrivateIp:        "10.0.1.10",
+			flagNics:             []string{"subnet=subnet-2,private-ip=10.0.2.10,delete-on-termination=false"},
+			flagK8sNodeNameTag:   true,
+			flagPurgeOnRemove:    true,
+			flagRetryStatusCodes: []string{"429"},
+		},
+		CreateFlags: source.GetCreateFlags(),
+	}))
+	source.VmId = "i-12345678"
+	writeMachineConfig(t, storePath, "source", "outscale", source)
+
+	// The credentials of the source machine are not used
+	os.Setenv("OSC_ACCESS_KEY", "ENV_ACCESS_KEY")
+	os.Setenv("OSC_SECRET_KEY", "ENV_SECRET_KEY")
+
+	clone := NewDriver("clone", storePath)
+	assert.NoError(t, clone.SetConfigFromFlags(&drivers.CheckDriverOptions{
+		FlagsValues: map[string]interface{}{
+			flagCloneFrom:    "source",
+			flagInstanceType: "tinav5.c8r16p1",
+		},
+		CreateFlags: clone.GetCreateFlags(),
+	}))
+
+	assert.Equal(t, "ENV_ACCESS_KEY", clone.Ak)
+	assert.Equal(t, "ENV_SECRET_KEY", clone.Sk)
+	assert.Equal(t, "tinav5.c8r16p1", clone.InstanceType)
+	assert.Equal(t, []string{"team=ops"}, clone.ExtraTagsAll)
+	assert.Equal(t, int32(50), clone.RootDiskSize)
+	assert.Equal(t, "subnet-1", clone.SubnetId)
+	assert.True(t, clone.TagK8sNodeName)
+	assert.True(t, clone.PurgeOnRemove)
+	assert.Equal(t, []int{429}, clone.RetryStatusCodes)
+	assert.Equal(t, "", clone.VmId)
+
+	// The private IPs belong to the source machine
+	assert.Equal(t, "", clone.PrivateIp)
+	assert.Equal(t, []string{"subnet=subnet-2,delete-on-termination=false"}, clone.ExtraNics)
+}
+
+func TestCloneFromNegatedFlag(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("OSC_ACCESS_KEY", "ENV_ACCESS_KEY")
+	os.Setenv("OSC_SECRET_KEY", "ENV_SECRET_KEY")
+	storePath := t.TempDir()
+
+	source := newStoredDriver("source", storePath)
+	source.PurgeOnRemove = true
+	source.SnapshotOnRemove = true
+	writeMachineConfig(t, storePath, "source", "outscale", source)
+
+	clone := NewDriver("clone", storePath)
+	assert.NoError(t, clone.SetConfigFromFlags(&drivers.CheckDriverOptions{
+		FlagsValues: map[string]interface{}{
+			flagCloneFrom:                  "source",
+			negatedFlag(flagPurgeOnRemove): true,
+		},
+		CreateFlags: clone.GetCreateFlags(),
+	}))
+
+	assert.False(t, clone.PurgeOnRemove)
+	assert.True(t, clone.SnapshotOnRemove)
+	assert.Equal(t, "outscale-no-purge-on-remove", negatedFlag(flagPurgeOnRemove))
+}
+
+func TestCloneFromWithoutCredentials(t *testing.T) {
+	os.Clearenv()
+	storePath := t.TempDir()
+
+	source := newStoredDriver("source", storePath)
+	source.Ak = "SOURCE_ACCESS_KEY"
+	source.Sk = "SOURCE_SECRET_KEY"
+	writeMachineConfig(t, storePath, "source", "outscale", source)
+
+	clone := NewDriver("clone", storePath)
+	assert.EqualError(t, clone.SetConfigFromFlags(&drivers.CheckDriverOptions{
+		FlagsValues: map[string]interface{}{
+			flagCloneFrom: "source",
+		},
+		CreateFlags: clone.GetCreateFlags(),
+	}), "Outscale Access Key is required")
+}
+
+func TestCloneFromErrors(t *testing.T) {
+	os.Clearenv()
+	storePath := t.TempDir()
+	writeMachineConfig(t, storePath, "virtualbox", "virtualbox", map[string]string{})
+
+	driver := NewDriver("clone", storePath)
+	checkFlags := &drivers.CheckDriverOptions{
+		FlagsValues: map[string]interface{}{
+			flagCloneFrom: "virtualbox",
+		},
+		CreateFlags: driver.GetCreateFlags(),
+	}
+	assert.EqualError(t, driver.SetConfigFromFlags(checkFlags), "The machine 'virtualbox' is not an OUTSCALE machine (driver: 'virtualbox')")
+
+	checkFlags.FlagsValues[flagCloneFrom] = "missing"
+	assert.ErrorContains(t, driver.SetConfigFromFlags(checkFlags), "Error while reading the config of the machine 'missing'")
+}
@@ -0,0 +1,159 @@
+package outscale
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/docker/machine/libmachine/log"
+)
+
+// machineCommand is an operation on a machine of the store which
+// docker-machine has no command for. It is run by the driver binary:
+// docker-machine-driver-outscale <command> [options] <machine>
+type machineCommand struct {
+	usage string
+	// setUp declares the options of the command and returns its run function
+	setUp func(flags *flag.FlagSet) func(d *OscDriver, out io.Writer) error
+	// saves reports whether the command changes the config of the machine
+	saves bool
+}
+
+var machineCommands = map[string]machineCommand{
+	"resize": {
+		usage: "Change the VM type of the machine and grow its root volume",
+		setUp: func(flags *flag.FlagSet) func(d *OscDriver, out io.Writer) error {
+			vmType := flags.String("instance-type", "", "New VM type")
+			rootDiskSize := flags.Int("root-disk-size", 0, "New size of the root disk in GiB")
+			return func(d *OscDriver, out io.Writer) error {
+				return d.Resize(*vmType, int32(*rootDiskSize))
+			}
+		},
+		saves: true,
+	},
+	"grow-volume": {
+		usage: "Grow a volume of the running machine, its partition and its file system",
+		setUp: func(flags *flag.FlagSet) func(d *OscDriver, out io.Writer) error {
+			deviceName := flags.String("device", "", "Device name of the volume, e.g. /dev/xvdb (default: the root volume)")
+			size := flags.Int("size", 0, "New size of the volume in GiB")
+			mountPoint := flags.String("mount-point", "", "Mount point of the file system of a data volume")
+			return func(d *OscDriver, out io.Writer) error {
+				if *size <= 0 {
+					return errors.New("the size of the volume is required")
+				}
+				return d.GrowVolume(*deviceName, int32(*size), *mountPoint)
+			}
+		},
+		saves: true,
+	},
+	"create-command-line": {
+		usage: "Print the docker-machine create command line building a machine like this one",
+		setUp: func(flags *flag.FlagSet) func(d *OscDriver, out io.Writer) error {
+			name := flags.String("name", "", "Name of the new machine (default: <machine>-copy)")
+			return func(d *OscDriver, out io.Writer) error {
+				if *name == "" {
+					*name = d.GetMachineName() + "-copy"
+				}
+				fmt.Fprintln(out, d.CreateCommandLine(*name))
+				return nil
+			}
+		},
+	},
+	"snapshot": {
+		usage: "Snapshot every volume of the machine, which can be running, and print the snapshot ids",
+		setUp: func(flags *flag.FlagSet) func(d *OscDriver, out io.Writer) error {
+			return func(d *OscDriver, out io.Writer) error {
+				snapshotIds, err := d.Snapshot()
+				// The snapshots created before a failure are printed too
+				for _, snapshotId := range snapshotIds {
+					fmt.Fprintln(out, snapshotId)
+				}
+				return err
+			}
+		},
+	},
+	"create-image": {
+		usage: "Create an OMI from the machine and print its id",
+		setUp: func(flags *flag.FlagSet) func(d *OscDriver, out io.Writer) error {
+			name := flags.String("name", "", "Name of the OMI (default: docker-machine-<machine>-<timestamp>)")
+			return func(d *OscDriver, out io.Writer) error {
+				imageId, err := d.CreateImage(*name)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintln(out, imageId)
+				return nil
+			}
+		},
+	},
+}
+
+// loadCommandMachine loads the machine a command is run on. The tests replace
+// it to call a fake API.
+var loadCommandMachine = loadMachine
+
+// commandNames returns the names of the commands, for the error messages
+func commandNames() string {
+	names := make([]string, 0, len(machineCommands))
+	for name := range machineCommands {
+		names = append(names, fmt.Sprintf("'%s'", name))
+	}
+	sort.Strings(names)
+	return strings.Join(names, "|")
+}
+
+// RunCommand runs a machine command given by its arguments, e.g.
+// resize --instance-type tinav5.c4r8p1 node1
+func RunCommand(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("a command is required (expected: %v)", commandNames())
+	}
+
+	command, ok := machineCommands[args[0]]
+	if !ok {
+		return fmt.Errorf("the command '%v' is unknown (expected: %v)", args[0], commandNames())
+	}
+
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(out)
+	flags.Usage = func() {
+		fmt.Fprintf(out, "%v\n\nUsage: docker-machine-driver-outscale %v [options] <machine>\n\nOptions:\n", command.usage, args[0])
+		flags.PrintDefaults()
+	}
+	storePath := flags.String("storage-path", defaultStorePath(), "Store of docker-machine (MACHINE_STORAGE_PATH)")
+	run := command.setUp(flags)
+
+	if err := flags.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	if flags.NArg() != 1 {
+		return fmt.Errorf("the command '%v' requires one machine name (got: %v)", args[0], flags.Args())
+	}
+
+	d, err := loadCommandMachine(*storePath, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	err = run(d, out)
+
+	// A command failing halfway may have changed the machine, e.g. grown the
+	// volume but not its file system
+	if command.saves {
+		if saveErr := saveMachine(d); saveErr != nil {
+			if err == nil {
+				return saveErr
+			}
+			log.Warnf("Error while saving the config of the machine: %v", saveErr)
+		}
+	}
+
+	return err
+}
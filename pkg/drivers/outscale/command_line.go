@@ -0,0 +1,133 @@
+package outscale
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/docker/machine/libmachine/log"
+	"github.com/docker/machine/libmachine/mcnflag"
+)
+
+var shellSafeArgument = regexp.MustCompile(`^[A-Za-z0-9_./:=,@%+-]+$`)
+
+// createOptions returns the stored create options of the machine by flag
+// name, with the type of their flag. The credentials are left out, as the
+// options not stored by the driver version which created the machine.
+func (d *OscDriver) createOptions() map[string]interface{} {
+	statusCodes := make([]string, 0, len(d.RetryStatusCodes))
+	for _, statusCode := range d.RetryStatusCodes {
+		statusCodes = append(statusCodes, strconv.Itoa(statusCode))
+	}
+
+	options := map[string]interface{}{
+		flagRegion:             d.Region,
+		flagInstanceType:       d.InstanceType,
+		flagSourceOmi:          d.SourceOmi,
+		flagExtraTagsAll:       d.ExtraTagsAll,
+		flagExtraTagsInstances: d.ExtraTagsInstances,
+		flagSecurityGroupIds:   d.SecurityGroupIds,
+		flagRootDiskType:       d.RootDiskType,
+		flagRootDiskSize:       int(d.RootDiskSize),
+		flagRootDiskIo1Iops:    int(d.RootDiskIo1Iops),
+		flagRootDiskSnapshotId: d.RootDiskSnapshotId,
+		flagVolumeTypes:        d.VolumeTypes,
+		flagDataDisks:          d.DataDisks,
+		flagSubnetId:           d.SubnetId,
+		flagK8sNodeNameTag:     d.TagK8sNodeName,
+		flagRetryMaxAttempts:   d.RetryMaxAttempts,
+		flagRetryMaxDelay:      d.RetryMaxDelay,
+		flagRetryStatusCodes:   statusCodes,
+		flagRateLimit:          d.RateLimit,
+		flagRateLimitShared:    d.RateLimitShared,
+		flagSubregion:          d.Subregion,
+		flagTenancy:            d.Tenancy,
+		flagPlacementStrategy:  d.PlacementStrategy,
+		flagPlacementGroupTag:  d.PlacementGroupTag,
+		flagPlacementSubnetIds: d.PlacementSubnetIds,
+		flagNics:               d.ExtraNics,
+		flagPrivateIp:          d.PrivateIp,
+		flagSecondaryIps:       d.SecondaryIps,
+		flagSecurityGroupRules: d.SecurityGroupRules,
+		flagUserDataFile:       d.UserDataFile,
+		flagLoadBalancerNames:  d.LoadBalancerNames,
+		flagSnapshotOnRemove:   d.SnapshotOnRemove,
+		flagPurgeOnRemove:      d.PurgeOnRemove,
+	}
+
+	// A machine created by an older version of the driver has no value for the
+	// options it did not know, which it stores as zero values. They can not be
+	// told apart from a value set by the user only when the default of the
+	// flag is not the zero value: the others are left out.
+	var unknownOptions []string
+	for _, flag := range d.GetCreateFlags() {
+		value, ok := options[flag.String()]
+		if !ok || flag.Default() == nil || !reflect.ValueOf(value).IsZero() || reflect.ValueOf(flag.Default()).IsZero() {
+			continue
+		}
+		delete(options, flag.String())
+		unknownOptions = append(unknownOptions, flag.String())
+	}
+	if len(unknownOptions) > 0 {
+		log.Warnf("The options %v are not stored in the config of the machine '%v', their defaults apply", strings.Join(unknownOptions, ", "), d.GetMachineName())
+	}
+
+	// The subnet and the subregion are chosen by the strategy at each creation
+	if d.PlacementStrategy == placementStrategySpread {
+		options[flagSubnetId] = ""
+		options[flagSubregion] = ""
+	}
+
+	return options
+}
+
+// CreateCommandLine returns the docker-machine create command line building a
+// machine like this one under another name. The credentials are not included,
+// they are expected in the environment.
+func (d *OscDriver) CreateCommandLine(machineName string) string {
+	arguments := []string{"docker-machine", "create", "--driver", d.DriverName()}
+
+	options := d.createOptions()
+	for _, flag := range d.GetCreateFlags() {
+		value, ok := options[flag.String()]
+		if !ok || isDefaultFlagValue(flag, value) {
+			continue
+		}
+
+		switch value := value.(type) {
+		case bool:
+			arguments = append(arguments, "--"+flag.String())
+		case []string:
+			for _, item := range value {
+				arguments = append(arguments, shellQuote(fmt.Sprintf("--%v=%v", flag.String(), item)))
+			}
+		default:
+			arguments = append(arguments, shellQuote(fmt.Sprintf("--%v=%v", flag.String(), value)))
+		}
+	}
+
+	return strings.Join(append(arguments, shellQuote(machineName)), " ")
+}
+
+// isDefaultFlagValue reports whether the flag can be omitted: its value is
+// the default one. The default of a bool flag is false, the one of a string
+// slice flag is empty.
+func isDefaultFlagValue(flag mcnflag.Flag, value interface{}) bool {
+	switch value := value.(type) {
+	case bool:
+		return !value
+	case []string:
+		return len(value) == 0
+	default:
+		return reflect.DeepEqual(flag.Default(), value)
+	}
+}
+
+func shellQuote(argument string) string {
+	if shellSafeArgument.MatchString(argument) {
+		return argument
+	}
+	return "'" + strings.ReplaceAll(argument, "'", `'\''`) + "'"
+}
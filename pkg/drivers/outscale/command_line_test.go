@@ -0,0 +1,220 @@
+package outscale
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/docker/machine/libmachine/drivers"
+	"github.com/docker/machine/libmachine/mcnflag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateCommandLine(t *testing.T) {
+	os.Clearenv()
+	driver := NewDriver("source", "")
+
+	os.Setenv("OSC_ACCESS_KEY", "OSC_ACCESS_KEY")
+	os.Setenv("OSC_SECRET_KEY", "OSC_SECRET_KEY")
+
+	flagsValues := map[string]interface{}{
+		flagRegion:           "eu-west-2",
+		flagInstanceType:     "tinav5.c4r8p1",
+		flagExtraTagsAll:     []string{"team=ops", "env=prod"},
+		flagSecurityGroupIds: []string{"sg-1"},
+		flagRootDiskType:     "io1",
+		flagRootDiskSize:     50,
+		flagRootDiskIo1Iops:  3000,
+		flagDataDisks:        []string{"size=100,type=gp2"},
+		flagSubnetId:         "subnet-1",
+		flagNics:             []string{"subnet=subnet-2,sgs=sg-1:sg-2"},
+		flagK8sNodeNameTag:   true,
+		flagRetryStatusCodes: []string{"429", "502"},
+	}
+	checkFlags := &drivers.CheckDriverOptions{
+		FlagsValues: flagsValues,
+		CreateFlags: driver.GetCreateFlags(),
+	}
+	assert.NoError(t, driver.SetConfigFromFlags(checkFlags))
+
+	assert.Equal(t, "docker-machine create --driver outscale"+
+		" --outscale-region=eu-west-2"+
+		" --outscale-instance-type=tinav5.c4r8p1"+
+		" --outscale-extra-tags-all=team=ops --outscale-extra-tags-all=env=prod"+
+		" --outscale-security-group-ids=sg-1"+
+		" --outscale-root-disk-type=io1 --outscale-root-disk-size=50 --outscale-root-disk-iops=3000"+
+		" --outscale-data-disk=size=100,type=gp2"+
+		" --outscale-subnet-id=subnet-1"+
+		" --outscale-kubernetes-node-name-autotag"+
+		" --outscale-retry-status-codes=429 --outscale-retry-status-codes=502"+
+		" --outscale-nic=subnet=subnet-2,sgs=sg-1:sg-2"+
+		" clone", driver.CreateCommandLine("clone"))
+
+	// The stored options give back the same machine
+	clone := NewDriver("clone", "")
+	assert.NoError(t, clone.SetConfigFromFlags(&drivers.CheckDriverOptions{
+		FlagsValues: driver.createOptions(),
+		CreateFlags: clone.GetCreateFlags(),
+	}))
+	assert.Equal(t, driver.createOptions(), clone.createOptions())
+}
+
+// newStoredDriver returns a driver with the options which always have a
+// value once stored
+func newStoredDriver(machineName string, storePath string) *OscDriver {
+	driver := NewDriver(machineName, storePath)
+	driver.InstanceType = defaultOscVmType
+	driver.SourceOmi = defaultOscOMI
+	driver.RootDiskType = defaultRootDiskType
+	driver.RootDiskSize = defaultRootDiskSize
+	return driver
+}
+
+func TestCreateCommandLineSpread(t *testing.T) {
+	driver := newStoredDriver("source", "")
+	driver.PlacementStrategy = placementStrategySpread
+	driver.PlacementGroupTag = "cluster=prod"
+	driver.PlacementSubnetIds = []string{"subnet-a", "subnet-b"}
+	// Chosen at the creation
+	driver.SubnetId = "subnet-b"
+	driver.Subregion = "eu-west-2b"
+
+	assert.Equal(t, "docker-machine create --driver outscale"+
+		" --outscale-placement-strategy=spread --outscale-placement-group-tag=cluster=prod"+
+		" --outscale-placement-subnet-ids=subnet-a --outscale-placement-subnet-ids=subnet-b"+
+		" node", driver.CreateCommandLine("node"))
+}
+
+func TestCreateCommandLineOlderMachine(t *testing.T) {
+	// The options unknown to an older driver are stored as zero values
+	driver := NewDriver("source", "")
+
+	assert.Equal(t, "docker-machine create --driver outscale node", driver.CreateCommandLine("node"))
+}
+
+// legacyMachineConfig is the config.json of a machine created by the driver
+// before it stored the create options
+const legacyMachineConfig = `{
+    "ConfigVersion": 3,
+    "Driver": {
+        "IPAddress": "192.0.2.10",
+        "MachineName": "legacy",
+        "SSHUser": "outscale",
+        "SSHPort": 22,
+        "SSHKeyPath": "",
+        "StorePath": "",
+        "SwarmMaster": false,
+        "SwarmHost": "",
+        "SwarmDiscovery": "",
+        "Ak": "ACCESS_KEY",
+        "Sk": "SECRET_KEY",
+        "Region": "cloudgouv-eu-west-1",
+        "VmId": "i-12345678",
+        "KeypairName": "docker-machine-legacy-1600000000",
+        "SecurityGroupId": "sg-12345678",
+        "PublicIpId": "eipalloc-12345678",
+        "PublicCloud": false
+    },
+    "DriverName": "outscale",
+    "HostOptions": {},
+    "Name": "legacy"
+}`
+
+// parseCreateCommandLine returns the flag values of a command line printed by
+// CreateCommandLine, which has no quoted argument
+func parseCreateCommandLine(t *testing.T, commandLine string, createFlags []mcnflag.Flag) map[string]interface{} {
+	arguments := strings.Fields(commandLine)
+	assert.Equal(t, []string{"docker-machine", "create", "--driver", "outscale"}, arguments[:4])
+
+	values := make(map[string]interface{})
+	for _, argument := range arguments[4 : len(arguments)-1] {
+		name, value, _ := strings.Cut(strings.TrimPrefix(argument, "--"), "=")
+		for _, flag := range createFlags {
+			if flag.String() != name {
+				continue
+			}
+			switch flag.(type) {
+			case mcnflag.BoolFlag:
+				values[name] = true
+			case mcnflag.StringSliceFlag:
+				previous, _ := values[name].([]string)
+				values[name] = append(previous, value)
+			case mcnflag.IntFlag:
+				number, err := strconv.Atoi(value)
+				assert.NoError(t, err)
+				values[name] = number
+			default:
+				values[name] = value
+			}
+		}
+		assert.Contains(t, values, name)
+	}
+	return values
+}
+
+func TestCreateCommandLineLegacyMachine(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("OSC_ACCESS_KEY", "OSC_ACCESS_KEY")
+	os.Setenv("OSC_SECRET_KEY", "OSC_SECRET_KEY")
+
+	storePath := t.TempDir()
+	machinePath := filepath.Join(storePath, "machines", "legacy")
+	assert.NoError(t, os.MkdirAll(machinePath, 0700))
+	assert.NoError(t, os.WriteFile(filepath.Join(machinePath, "config.json"), []byte(legacyMachineConfig), 0600))
+
+	var out bytes.Buffer
+	assert.NoError(t, RunCommand([]string{"create-command-line", "--storage-path", storePath, "--name", "node2", "legacy"}, &out))
+	commandLine := strings.TrimSuffix(out.String(), "\n")
+	assert.Equal(t, "docker-machine create --driver outscale --outscale-region=cloudgouv-eu-west-1 node2", commandLine)
+
+	// The command line is accepted
+	driver := NewDriver("node2", storePath)
+	assert.NoError(t, driver.SetConfigFromFlags(&drivers.CheckDriverOptions{
+		FlagsValues: parseCreateCommandLine(t, commandLine, driver.GetCreateFlags()),
+		CreateFlags: driver.GetCreateFlags(),
+	}))
+	assert.Equal(t, "cloudgouv-eu-west-1", driver.Region)
+	assert.Equal(t, defaultOscVmType, driver.InstanceType)
+}
+
+func TestIsDefaultFlagValue(t *testing.T) {
+	driver := NewDriver("source", "")
+	flags := map[string]mcnflag.Flag{}
+	for _, flag := range driver.GetCreateFlags() {
+		flags[flag.String()] = flag
+	}
+
+	assert.True(t, isDefaultFlagValue(flags[flagRootDiskSize], defaultRootDiskSize))
+	assert.False(t, isDefaultFlagValue(flags[flagRootDiskSize], 0))
+	assert.True(t, isDefaultFlagValue(flags[flagRateLimit], 0))
+	assert.True(t, isDefaultFlagValue(flags[flagPurgeOnRemove], false))
+	assert.False(t, isDefaultFlagValue(flags[flagPurgeOnRemove], true))
+	assert.True(t, isDefaultFlagValue(flags[flagDataDisks], []string{}))
+	assert.False(t, isDefaultFlagValue(flags[flagDataDisks], []string{"size=10"}))
+	assert.True(t, isDefaultFlagValue(flags[flagSubnetId], ""))
+	assert.False(t, isDefaultFlagValue(flags[flagInstanceType], ""))
+}
+
+func TestRunCommandCreateCommandLine(t *testing.T) {
+	storePath := t.TempDir()
+	driver := newStoredDriver("node1", storePath)
+	driver.InstanceType = "tinav5.c4r8p1"
+	writeMachineConfig(t, storePath, "node1", "outscale", driver)
+
+	var out bytes.Buffer
+	assert.NoError(t, RunCommand([]string{"create-command-line", "--storage-path", storePath, "node1"}, &out))
+	assert.Equal(t, "docker-machine create --driver outscale --outscale-instance-type=tinav5.c4r8p1 node1-copy\n", out.String())
+
+	out.Reset()
+	assert.NoError(t, RunCommand([]string{"create-command-line", "--storage-path", storePath, "--name", "node2", "node1"}, &out))
+	assert.Equal(t, "docker-machine create --driver outscale --outscale-instance-type=tinav5.c4r8p1 node2\n", out.String())
+}
+
+func TestShellQuote(t *testing.T) {
+	assert.Equal(t, "--outscale-extra-tags-all=team=ops", shellQuote("--outscale-extra-tags-all=team=ops"))
+	assert.Equal(t, "'--outscale-extra-tags-all=name=my machine'", shellQuote("--outscale-extra-tags-all=name=my machine"))
+	assert.Equal(t, `'it'\''s'`, shellQuote("it's"))
+}
@@ -0,0 +1,44 @@
+package outscale
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunCommandErrors(t *testing.T) {
+	var out bytes.Buffer
+	storePath := t.TempDir()
+
+	assert.EqualError(t, RunCommand(nil, &out), "a command is required (expected: "+commandNames()+")")
+	assert.EqualError(t, RunCommand([]string{"explode"}, &out), "the command 'explode' is unknown (expected: "+commandNames()+")")
+	assert.EqualError(t, RunCommand([]string{"resize", "--storage-path", storePath}, &out), "the command 'resize' requires one machine name (got: [])")
+	assert.ErrorContains(t, RunCommand([]string{"resize", "--storage-path", storePath, "--instance-type", "tinav5.c4r8p1", "missing"}, &out), "Error while reading the config of the machine 'missing'")
+	assert.Error(t, RunCommand([]string{"resize", "--size", "10", "node1"}, &out))
+}
+
+func TestRunCommandHelp(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, RunCommand([]string{"resize", "--help"}, &out))
+	assert.Contains(t, out.String(), "Usage: docker-machine-driver-outscale resize [options] <machine>")
+	assert.Contains(t, out.String(), "-root-disk-size")
+}
+
+func TestRunCommandCreateImageHelp(t *testing.T) {
+	var out bytes.Buffer
+	assert.NoError(t, RunCommand([]string{"create-image", "--help"}, &out))
+	assert.Contains(t, out.String(), "Usage: docker-machine-driver-outscale create-image [options] <machine>")
+	assert.Contains(t, out.String(), "-name")
+}
+
+func TestRunCommandGrowVolume(t *testing.T) {
+	var out bytes.Buffer
+	storePath := t.TempDir()
+	writeMachineConfig(t, storePath, "node1", "outscale", NewDriver("node1", storePath))
+
+	assert.EqualError(t, RunCommand([]string{"grow-volume", "--storage-path", storePath, "node1"}, &out), "the size of the volume is required")
+
+	assert.NoError(t, RunCommand([]string{"grow-volume", "--help"}, &out))
+	assert.Contains(t, out.String(), "-mount-point")
+}
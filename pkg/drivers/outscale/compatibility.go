@@ -0,0 +1,239 @@
+package outscale
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/machine/libmachine/log"
+	osc "github.com/outscale/osc-sdk-go/v2"
+)
+
+// CompatibilityError lists all the problems found between the subnet, the
+// subregion, the security groups and the OMI requested for the machine
+type CompatibilityError struct {
+	Problems []string
+}
+
+func (e CompatibilityError) Error() string {
+	return fmt.Sprintf("The requested configuration is not valid:\n  - %v", strings.Join(e.Problems, "\n  - "))
+}
+
+// checkCompatibility checks that the subnet, the subregion, the security
+// groups, the OMI and the snapshots exist and can be used together. It retrieves the Net of the subnet.
+func (d *OscDriver) checkCompatibility() error {
+	var problems []string
+
+	// Check the SubnetId
+	subnetFound := true
+	var subnets []osc.Subnet
+	if !d.PublicCloud {
+		var err error
+		subnets, err = readSubnets(d, osc.FiltersSubnet{
+			SubnetIds: &[]string{d.SubnetId},
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(subnets) == 0 {
+			subnetFound = false
+			problems = append(problems, fmt.Sprintf("The Subnet Id '%v' does not exist, check --%v.", d.SubnetId, flagSubnetId))
+		} else {
+			d.netId = subnets[0].GetNetId()
+			log.Debugf("The Subnet Id '%v' exists in NetId '%v'", d.SubnetId, d.netId)
+
+			if d.Subregion != "" && d.Subregion != subnets[0].GetSubregionName() {
+				problems = append(problems, fmt.Sprintf("The Subnet '%v' is in the subregion '%v' but the subregion '%v' is requested, check --%v.", d.SubnetId, subnets[0].GetSubregionName(), d.Subregion, flagSubregion))
+			}
+
+			// Check the private IPs
+			privateIps := d.requestedPrivateIps()
+			problems = append(problems, privateIpProblems(privateIps, subnets[0])...)
+
+			conflicts, err := privateIpConflicts(d, privateIps, d.SubnetId)
+			if err != nil {
+				return err
+			}
+			problems = append(problems, conflicts...)
+		}
+	}
+
+	// Check the subnets of the additional NICs
+	if len(d.extraNics) > 0 && subnetFound {
+		nicProblems, err := d.extraNicProblems(subnets)
+		if err != nil {
+			return err
+		}
+		problems = append(problems, nicProblems...)
+	}
+
+	// Check the subregion
+	if d.Subregion != "" {
+		subregions, err := readSubregions(d, osc.FiltersSubregion{
+			SubregionNames: &[]string{d.Subregion},
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(subregions) == 0 {
+			problems = append(problems, fmt.Sprintf("The subregion '%v' does not exist in the region '%v', check --%v.", d.Subregion, d.Region, flagSubregion))
+		}
+	}
+
+	// Check the SG
+	if len(d.SecurityGroupIds) > 0 {
+		securityGroups, err := readSecurityGroups(d, osc.FiltersSecurityGroup{
+			SecurityGroupIds: &d.SecurityGroupIds,
+		})
+		if err != nil {
+			return err
+		}
+
+		problems = append(problems, d.securityGroupProblems(securityGroups, subnetFound)...)
+	}
+
+	// Check the OMI
+	images, err := readImages(d, osc.FiltersImage{
+		ImageIds: &[]string{d.SourceOmi},
+	})
+	if err != nil {
+		return err
+	}
+
+	problems = append(problems, d.imageProblems(images)...)
+
+	// Check the snapshots of the volumes
+	if requests := d.snapshotRequests(); len(requests) > 0 {
+		snapshotIds := make([]string, 0, len(requests))
+		for _, request := range requests {
+			snapshotIds = append(snapshotIds, request.snapshotId)
+		}
+
+		snapshots, err := readSnapshots(d, osc.FiltersSnapshot{
+			SnapshotIds: &snapshotIds,
+		})
+		if err != nil {
+			return err
+		}
+
+		problems = append(problems, d.snapshotProblems(snapshots)...)
+	}
+
+	if len(problems) > 0 {
+		return CompatibilityError{Problems: problems}
+	}
+
+	return nil
+}
+
+func (d *OscDriver) securityGroupProblems(securityGroups []osc.SecurityGroup, subnetFound bool) []string {
+	var problems []string
+
+	found := make(map[string]osc.SecurityGroup)
+	for _, securityGroup := range securityGroups {
+		found[securityGroup.GetSecurityGroupId()] = securityGroup
+	}
+
+	for _, sgId := range d.SecurityGroupIds {
+		securityGroup, ok := found[sgId]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("The Security Group '%v' does not exist, check --%v.", sgId, flagSecurityGroupIds))
+			continue
+		}
+
+		sgNetId := securityGroup.GetNetId()
+		switch {
+		case d.PublicCloud && sgNetId != "":
+			problems = append(problems, fmt.Sprintf("The Security Group '%v' belongs to the Net '%v' but the machine is created in the public cloud, set --%v to a subnet of this Net or use a Security Group without Net.", sgId, sgNetId, flagSubnetId))
+		case !d.PublicCloud && subnetFound && sgNetId != d.netId:
+			if sgNetId == "" {
+				problems = append(problems, fmt.Sprintf("The Security Group '%v' belongs to the public cloud but the Subnet '%v' is in the Net '%v', use a Security Group of this Net.", sgId, d.SubnetId, d.netId))
+			} else {
+				problems = append(problems, fmt.Sprintf("The Security Group '%v' belongs to the Net '%v' but the Subnet '%v' is in the Net '%v', use a Security Group of the same Net.", sgId, sgNetId, d.SubnetId, d.netId))
+			}
+		default:
+			log.Debugf("The Security Group '%v' exists.", sgId)
+		}
+	}
+
+	return problems
+}
+
+func (d *OscDriver) imageProblems(images []osc.Image) []string {
+	if len(images) == 0 {
+		return []string{fmt.Sprintf("The OMI '%v' does not exist in the region '%v' or is not shared with the account, check --%v.", d.SourceOmi, d.Region, flagSourceOmi)}
+	}
+
+	if imageState := images[0].GetState(); imageState != "available" {
+		return []string{fmt.Sprintf("The OMI '%v' is not available (state: '%v'), wait for it or use another OMI.", d.SourceOmi, imageState)}
+	}
+
+	log.Debugf("The OMI '%v' is available.", d.SourceOmi)
+	return nil
+}
+
+// extraNicProblems checks that the subnets of the additional NICs exist in the
+// Net and the subregion of the subnet of the machine, and that their private
+// IPs can be assigned in them
+func (d *OscDriver) extraNicProblems(machineSubnets []osc.Subnet) ([]string, error) {
+	subnetIds := make([]string, 0, len(d.extraNics))
+	for _, nic := range d.extraNics {
+		subnetIds = append(subnetIds, nic.subnetId)
+	}
+
+	subnets, err := readSubnets(d, osc.FiltersSubnet{
+		SubnetIds: &subnetIds,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	problems := nicSubnetProblems(d.extraNics, subnets, machineSubnets[0])
+
+	found := make(map[string]osc.Subnet)
+	for _, subnet := range subnets {
+		found[subnet.GetSubnetId()] = subnet
+	}
+
+	for _, nic := range d.extraNics {
+		subnet, ok := found[nic.subnetId]
+		if !ok || nic.privateIp == "" {
+			continue
+		}
+
+		privateIps := []string{nic.privateIp}
+		problems = append(problems, privateIpProblems(privateIps, subnet)...)
+
+		conflicts, err := privateIpConflicts(d, privateIps, nic.subnetId)
+		if err != nil {
+			return nil, err
+		}
+		problems = append(problems, conflicts...)
+	}
+
+	return problems, nil
+}
+
+func nicSubnetProblems(nics []nicSpec, subnets []osc.Subnet, machineSubnet osc.Subnet) []string {
+	var problems []string
+
+	found := make(map[string]osc.Subnet)
+	for _, subnet := range subnets {
+		found[subnet.GetSubnetId()] = subnet
+	}
+
+	for _, nic := range nics {
+		subnet, ok := found[nic.subnetId]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("The Subnet '%v' of an additional NIC does not exist, check --%v.", nic.subnetId, flagNics))
+		case subnet.GetNetId() != machineSubnet.GetNetId():
+			problems = append(problems, fmt.Sprintf("The Subnet '%v' of an additional NIC is in the Net '%v' but the machine is in the Net '%v'.", nic.subnetId, subnet.GetNetId(), machineSubnet.GetNetId()))
+		case subnet.GetSubregionName() != machineSubnet.GetSubregionName():
+			problems = append(problems, fmt.Sprintf("The Subnet '%v' of an additional NIC is in the subregion '%v' but the machine is in the subregion '%v'.", nic.subnetId, subnet.GetSubregionName(), machineSubnet.GetSubregionName()))
+		}
+	}
+
+	return problems
+}
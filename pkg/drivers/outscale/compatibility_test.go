@@ -0,0 +1,54 @@
+package outscale
+
+import (
+	"testing"
+
+	osc "github.com/outscale/osc-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityGroupProblems(t *testing.T) {
+	securityGroups := []osc.SecurityGroup{
+		{SecurityGroupId: osc.PtrString("sg-public")},
+		{SecurityGroupId: osc.PtrString("sg-net1"), NetId: osc.PtrString("vpc-1")},
+		{SecurityGroupId: osc.PtrString("sg-net2"), NetId: osc.PtrString("vpc-2")},
+	}
+
+	driver := NewDriver("", "")
+	driver.PublicCloud = true
+	driver.SecurityGroupIds = []string{"sg-public", "sg-net1", "sg-missing"}
+
+	assert.Equal(t, []string{
+		"The Security Group 'sg-net1' belongs to the Net 'vpc-1' but the machine is created in the public cloud, set --outscale-subnet-id to a subnet of this Net or use a Security Group without Net.",
+		"The Security Group 'sg-missing' does not exist, check --outscale-security-group-ids.",
+	}, driver.securityGroupProblems(securityGroups, true))
+
+	driver.PublicCloud = false
+	driver.SubnetId = "subnet-1"
+	driver.netId = "vpc-1"
+	driver.SecurityGroupIds = []string{"sg-public", "sg-net1", "sg-net2"}
+
+	assert.Equal(t, []string{
+		"The Security Group 'sg-public' belongs to the public cloud but the Subnet 'subnet-1' is in the Net 'vpc-1', use a Security Group of this Net.",
+		"The Security Group 'sg-net2' belongs to the Net 'vpc-2' but the Subnet 'subnet-1' is in the Net 'vpc-1', use a Security Group of the same Net.",
+	}, driver.securityGroupProblems(securityGroups, true))
+
+	// The Net can not be compared when the subnet does not exist
+	assert.Empty(t, driver.securityGroupProblems(securityGroups, false))
+}
+
+func TestImageProblems(t *testing.T) {
+	driver := NewDriver("", "")
+	driver.Region = "eu-west-2"
+	driver.SourceOmi = "ami-12345678"
+
+	assert.Equal(t, []string{
+		"The OMI 'ami-12345678' does not exist in the region 'eu-west-2' or is not shared with the account, check --outscale-source-omi.",
+	}, driver.imageProblems(nil))
+
+	assert.Equal(t, []string{
+		"The OMI 'ami-12345678' is not available (state: 'pending'), wait for it or use another OMI.",
+	}, driver.imageProblems([]osc.Image{{ImageId: osc.PtrString("ami-12345678"), State: osc.PtrString("pending")}}))
+
+	assert.Empty(t, driver.imageProblems([]osc.Image{{ImageId: osc.PtrString("ami-12345678"), State: osc.PtrString("available")}}))
+}
@@ -0,0 +1,547 @@
+package outscale
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// machineDescription is the content of a config file, a YAML or JSON
+// document describing the machine. Each field stands for a flag, and an
+// absent one for its default.
+type machineDescription struct {
+	Credentials   credentialsDescription `key:"credentials"`
+	Region        string                 `key:"region"`
+	Vm            vmDescription          `key:"vm"`
+	Disks         disksDescription       `key:"disks"`
+	Network       networkDescription     `key:"network"`
+	Placement     placementDescription   `key:"placement"`
+	Tags          tagsDescription        `key:"tags"`
+	LoadBalancers []string               `key:"loadBalancers"`
+}
+
+// credentialsDescription references the environment variables holding the
+// keys, which are not written in the file
+type credentialsDescription struct {
+	AccessKeyEnv string `key:"accessKeyEnv"`
+	SecretKeyEnv string `key:"secretKeyEnv"`
+}
+
+type vmDescription struct {
+	Type                  string `key:"type"`
+	Omi                   string `key:"omi"`
+	UserDataFile          string `key:"userDataFile"`
+	KubernetesNodeNameTag bool   `key:"kubernetesNodeNameTag"`
+}
+
+type disksDescription struct {
+	Root diskDescription   `key:"root"`
+	Data []diskDescription `key:"data"`
+}
+
+type diskDescription struct {
+	Type     string `key:"type"`
+	Size     int32  `key:"size"`
+	Iops     int32  `key:"iops"`
+	Snapshot string `key:"snapshot"`
+}
+
+type networkDescription struct {
+	Subnet              string                         `key:"subnet"`
+	PrivateIp           string                         `key:"privateIp"`
+	SecondaryPrivateIps []string                       `key:"secondaryPrivateIps"`
+	SecurityGroups      []string                       `key:"securityGroups"`
+	SecurityGroupRules  []securityGroupRuleDescription `key:"securityGroupRules"`
+	Nics                []nicDescription               `key:"nics"`
+}
+
+type securityGroupRuleDescription struct {
+	Protocol string `key:"protocol"`
+	FromPort int32  `key:"fromPort"`
+	ToPort   int32  `key:"toPort"`
+	IpRange  string `key:"ipRange"`
+}
+
+type nicDescription struct {
+	Subnet              string   `key:"subnet"`
+	SecurityGroups      []string `key:"securityGroups"`
+	PrivateIp           string   `key:"privateIp"`
+	DeleteOnTermination *bool    `key:"deleteOnTermination"`
+}
+
+type placementDescription struct {
+	Subregion string   `key:"subregion"`
+	Tenancy   string   `key:"tenancy"`
+	Strategy  string   `key:"strategy"`
+	GroupTag  string   `key:"groupTag"`
+	Subnets   []string `key:"subnets"`
+}
+
+type tagsDescription struct {
+	All       map[string]string `key:"all"`
+	Instances map[string]string `key:"instances"`
+}
+
+// configFileOptions reads a config file and returns its options by flag name.
+// The disks are checked against the default volume types completed by the
+// given ones.
+func configFileOptions(path string, volumeTypeSpecs []string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("Error while reading the config file: %w", err)
+	}
+
+	description, err := parseMachineDescription(content, strings.EqualFold(filepath.Ext(path), ".json"))
+	if err != nil {
+		return nil, fmt.Errorf("the config file '%v' is not valid: %v", path, err)
+	}
+
+	volumeTypes, err := volumeTypesWith(volumeTypeSpecs)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := description.options(filepath.Dir(path), volumeTypes)
+	if err != nil {
+		return nil, fmt.Errorf("the config file '%v' is not valid: %v", path, err)
+	}
+
+	return options, nil
+}
+
+// parseMachineDescription decodes a YAML, or JSON, document. The errors give
+// the path of the faulty field, e.g. 'disks.data[1].size'.
+func parseMachineDescription(content []byte, isJson bool) (machineDescription, error) {
+	var document interface{}
+	if isJson {
+		if err := json.Unmarshal(content, &document); err != nil {
+			return machineDescription{}, err
+		}
+	} else {
+		if err := yaml.Unmarshal(content, &document); err != nil {
+			return machineDescription{}, err
+		}
+	}
+
+	var description machineDescription
+	if document == nil {
+		return description, nil
+	}
+
+	if err := decodeDescription("", document, reflect.ValueOf(&description).Elem()); err != nil {
+		return machineDescription{}, err
+	}
+
+	return description, nil
+}
+
+// decodeDescription sets the target from a decoded YAML or JSON value
+func decodeDescription(path string, value interface{}, target reflect.Value) error {
+	// An empty value, e.g. 'tags:' in YAML, is an absent one
+	if value == nil {
+		return nil
+	}
+
+	switch target.Kind() {
+	case reflect.Struct:
+		fields, ok := value.(map[string]interface{})
+		if !ok {
+			return descriptionTypeError(path, "an object")
+		}
+
+		keys := make([]string, 0, len(fields))
+		for key := range fields {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			field, ok := descriptionField(target, key)
+			if !ok {
+				return fmt.Errorf("%v: the key is unknown (expected: %v)", joinDescriptionPath(path, key), descriptionKeys(target.Type()))
+			}
+			if err := decodeDescription(joinDescriptionPath(path, key), fields[key], field); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		items, ok := value.([]interface{})
+		if !ok {
+			return descriptionTypeError(path, "a list")
+		}
+
+		slice := reflect.MakeSlice(target.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := decodeDescription(fmt.Sprintf("%v[%v]", path, i), item, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		target.Set(slice)
+	case reflect.Map:
+		entries, ok := value.(map[string]interface{})
+		if !ok {
+			return descriptionTypeError(path, "an object")
+		}
+
+		m := reflect.MakeMapWithSize(target.Type(), len(entries))
+		for key, entry := range entries {
+			element := reflect.New(target.Type().Elem()).Elem()
+			if err := decodeDescription(joinDescriptionPath(path, key), entry, element); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(key), element)
+		}
+		target.Set(m)
+	case reflect.Ptr:
+		element := reflect.New(target.Type().Elem())
+		if err := decodeDescription(path, value, element.Elem()); err != nil {
+			return err
+		}
+		target.Set(element)
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return descriptionTypeError(path, "a string")
+		}
+		target.SetString(s)
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return descriptionTypeError(path, "a boolean")
+		}
+		target.SetBool(b)
+	case reflect.Int32:
+		// YAML gives an int and JSON a float64
+		var number float64
+		switch value := value.(type) {
+		case int:
+			number = float64(value)
+		case float64:
+			number = value
+		default:
+			return descriptionTypeError(path, "an integer")
+		}
+		if number != math.Trunc(number) || number < math.MinInt32 || number > math.MaxInt32 {
+			return descriptionTypeError(path, "an integer")
+		}
+		target.SetInt(int64(number))
+	default:
+		return fmt.Errorf("%v: the type %v is not supported", path, target.Type())
+	}
+
+	return nil
+}
+
+// descriptionField returns the field of the struct having the key
+func descriptionField(target reflect.Value, key string) (reflect.Value, bool) {
+	for i := 0; i < target.NumField(); i++ {
+		if target.Type().Field(i).Tag.Get("key") == key {
+			return target.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// descriptionKeys returns the accepted keys of a struct, for the error
+// messages
+func descriptionKeys(t reflect.Type) string {
+	keys := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		keys = append(keys, fmt.Sprintf("'%s'", t.Field(i).Tag.Get("key")))
+	}
+	return strings.Join(keys, "|")
+}
+
+func descriptionTypeError(path string, expected string) error {
+	if path == "" {
+		return fmt.Errorf("the document is not accepted, it must be %v", expected)
+	}
+	return fmt.Errorf("%v: the value is not accepted, it must be %v", path, expected)
+}
+
+func joinDescriptionPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// options returns the options of the description by flag name. The absent
+// fields are left out, so that their default applies. Each field is checked
+// like its flag, the errors giving the path of the field. The relative paths
+// are relative to the directory of the config file.
+func (m machineDescription) options(dir string, volumeTypes volumeTypeCatalog) (map[string]interface{}, error) {
+	options := make(map[string]interface{})
+
+	setString := func(key string, value string) {
+		if value != "" {
+			options[key] = value
+		}
+	}
+
+	setStringSlice := func(key string, values []string) {
+		if len(values) > 0 {
+			options[key] = values
+		}
+	}
+
+	// Credentials
+	credentials := []struct {
+		path string
+		env  string
+		flag string
+	}{
+		{"credentials.accessKeyEnv", m.Credentials.AccessKeyEnv, flagAccessKey},
+		{"credentials.secretKeyEnv", m.Credentials.SecretKeyEnv, flagSecretKey},
+	}
+	for _, credential := range credentials {
+		if credential.env == "" {
+			continue
+		}
+		value := os.Getenv(credential.env)
+		if value == "" {
+			return nil, fmt.Errorf("%v: the environment variable '%v' is not set", credential.path, credential.env)
+		}
+		options[credential.flag] = value
+	}
+
+	setString(flagRegion, m.Region)
+
+	// VM
+	setString(flagInstanceType, m.Vm.Type)
+	setString(flagSourceOmi, m.Vm.Omi)
+
+	if m.Vm.UserDataFile != "" {
+		userDataFile := m.Vm.UserDataFile
+		if !filepath.IsAbs(userDataFile) {
+			userDataFile = filepath.Join(dir, userDataFile)
+		}
+		if _, err := readUserData(userDataFile); err != nil {
+			return nil, fmt.Errorf("vm.userDataFile: %v", err)
+		}
+		options[flagUserDataFile] = userDataFile
+	}
+
+	if m.Vm.KubernetesNodeNameTag {
+		options[flagK8sNodeNameTag] = true
+	}
+
+	// Disks
+	if err := m.Disks.Root.validateRoot(volumeTypes); err != nil {
+		return nil, fmt.Errorf("disks.root: %v", err)
+	}
+	setString(flagRootDiskType, m.Disks.Root.Type)
+	if m.Disks.Root.Size != 0 {
+		options[flagRootDiskSize] = int(m.Disks.Root.Size)
+	}
+	if m.Disks.Root.Iops != 0 {
+		options[flagRootDiskIo1Iops] = int(m.Disks.Root.Iops)
+	}
+	setString(flagRootDiskSnapshotId, m.Disks.Root.Snapshot)
+
+	var dataDisks []string
+	for i, disk := range m.Disks.Data {
+		spec := disk.spec()
+		if spec == "" {
+			return nil, fmt.Errorf("disks.data[%v]: the disk is empty", i)
+		}
+		if _, err := parseDataDiskSpec(spec, volumeTypes); err != nil {
+			return nil, fmt.Errorf("disks.data[%v]: %v", i, err)
+		}
+		dataDisks = append(dataDisks, spec)
+	}
+	setStringSlice(flagDataDisks, dataDisks)
+
+	// Network
+	setString(flagSubnetId, m.Network.Subnet)
+
+	if m.Network.PrivateIp != "" {
+		if err := parsePrivateIps([]string{m.Network.PrivateIp}); err != nil {
+			return nil, fmt.Errorf("network.privateIp: %v", err)
+		}
+	}
+	setString(flagPrivateIp, m.Network.PrivateIp)
+
+	for i, ip := range m.Network.SecondaryPrivateIps {
+		if err := parsePrivateIps([]string{ip}); err != nil {
+			return nil, fmt.Errorf("network.secondaryPrivateIps[%v]: %v", i, err)
+		}
+	}
+	setStringSlice(flagSecondaryIps, m.Network.SecondaryPrivateIps)
+
+	setStringSlice(flagSecurityGroupIds, m.Network.SecurityGroups)
+
+	var securityGroupRules []string
+	for i, rule := range m.Network.SecurityGroupRules {
+		spec := rule.spec()
+		if spec == "" {
+			return nil, fmt.Errorf("network.securityGroupRules[%v]: the rule is empty", i)
+		}
+		if _, err := parseSecurityGroupRuleSpec(spec); err != nil {
+			return nil, fmt.Errorf("network.securityGroupRules[%v]: %v", i, err)
+		}
+		securityGroupRules = append(securityGroupRules, spec)
+	}
+	setStringSlice(flagSecurityGroupRules, securityGroupRules)
+
+	var nics []string
+	for i, nic := range m.Network.Nics {
+		spec := nic.spec()
+		if spec == "" {
+			return nil, fmt.Errorf("network.nics[%v]: the NIC is empty", i)
+		}
+		if _, err := parseNicSpec(spec); err != nil {
+			return nil, fmt.Errorf("network.nics[%v]: %v", i, err)
+		}
+		nics = append(nics, spec)
+	}
+	setStringSlice(flagNics, nics)
+
+	// Placement
+	setString(flagSubregion, m.Placement.Subregion)
+
+	if m.Placement.Tenancy != "" && !validateTenancy(m.Placement.Tenancy) {
+		return nil, fmt.Errorf("placement.tenancy: the tenancy is not accepted (got: %s, expected: 'default'|'dedicated')", m.Placement.Tenancy)
+	}
+	setString(flagTenancy, m.Placement.Tenancy)
+
+	if !validatePlacementStrategy(m.Placement.Strategy) {
+		return nil, fmt.Errorf("placement.strategy: the placement strategy is not accepted (got: %s, expected: 'spread')", m.Placement.Strategy)
+	}
+	setString(flagPlacementStrategy, m.Placement.Strategy)
+
+	if m.Placement.GroupTag != "" && !validateExtraTagsFormat([]string{m.Placement.GroupTag}) {
+		return nil, fmt.Errorf("placement.groupTag: the tag '%v' does not have the syntax 'key=value'", m.Placement.GroupTag)
+	}
+	setString(flagPlacementGroupTag, m.Placement.GroupTag)
+	setStringSlice(flagPlacementSubnetIds, m.Placement.Subnets)
+
+	// Tags
+	tagsAll, err := tagsOfDescription("tags.all", m.Tags.All)
+	if err != nil {
+		return nil, err
+	}
+	setStringSlice(flagExtraTagsAll, tagsAll)
+
+	tagsInstances, err := tagsOfDescription("tags.instances", m.Tags.Instances)
+	if err != nil {
+		return nil, err
+	}
+	setStringSlice(flagExtraTagsInstances, tagsInstances)
+
+	setStringSlice(flagLoadBalancerNames, m.LoadBalancers)
+
+	return options, nil
+}
+
+// validateRoot checks the root disk with the defaults of its absent fields
+func (disk diskDescription) validateRoot(volumeTypes volumeTypeCatalog) error {
+	diskType := disk.Type
+	if diskType == "" {
+		diskType = defaultRootDiskType
+	}
+
+	if disk.Size < 0 {
+		return fmt.Errorf("the size (%v) is not accepted, it must be > 0", disk.Size)
+	}
+	size := disk.Size
+	if size == 0 {
+		size = defaultRootDiskSize
+	}
+
+	if disk.Iops < 0 {
+		return fmt.Errorf("the iops (%v) is not accepted, it must be > 0", disk.Iops)
+	}
+
+	// The iops are ignored when the type does not provision them
+	iops := int32(0)
+	if volumeTypes[diskType].maxIops > 0 {
+		if iops = disk.Iops; iops == 0 {
+			iops = defaultRootDiskIo1Iops
+		}
+	}
+
+	return volumeTypes.validate(diskType, size, iops)
+}
+
+// spec returns the disk as an --outscale-data-disk value
+func (disk diskDescription) spec() string {
+	var fields []string
+	if disk.Size != 0 {
+		fields = append(fields, fmt.Sprintf("size=%v", disk.Size))
+	}
+	if disk.Type != "" {
+		fields = append(fields, fmt.Sprintf("type=%v", disk.Type))
+	}
+	if disk.Iops != 0 {
+		fields = append(fields, fmt.Sprintf("iops=%v", disk.Iops))
+	}
+	if disk.Snapshot != "" {
+		fields = append(fields, fmt.Sprintf("snapshot=%v", disk.Snapshot))
+	}
+	return strings.Join(fields, ",")
+}
+
+// spec returns the rule as an --outscale-security-group-rule value. The port
+// range is a single port when toPort is absent.
+func (rule securityGroupRuleDescription) spec() string {
+	var fields []string
+	if rule.Protocol != "" {
+		fields = append(fields, fmt.Sprintf("protocol=%v", rule.Protocol))
+	}
+	if rule.FromPort != 0 {
+		if rule.ToPort != 0 {
+			fields = append(fields, fmt.Sprintf("ports=%v-%v", rule.FromPort, rule.ToPort))
+		} else {
+			fields = append(fields, fmt.Sprintf("ports=%v", rule.FromPort))
+		}
+	}
+	if rule.IpRange != "" {
+		fields = append(fields, fmt.Sprintf("ip-range=%v", rule.IpRange))
+	}
+	return strings.Join(fields, ",")
+}
+
+// spec returns the NIC as an --outscale-nic value
+func (nic nicDescription) spec() string {
+	var fields []string
+	if nic.Subnet != "" {
+		fields = append(fields, fmt.Sprintf("subnet=%v", nic.Subnet))
+	}
+	if len(nic.SecurityGroups) > 0 {
+		fields = append(fields, fmt.Sprintf("sgs=%v", strings.Join(nic.SecurityGroups, ":")))
+	}
+	if nic.PrivateIp != "" {
+		fields = append(fields, fmt.Sprintf("private-ip=%v", nic.PrivateIp))
+	}
+	if nic.DeleteOnTermination != nil {
+		fields = append(fields, fmt.Sprintf("delete-on-termination=%v", *nic.DeleteOnTermination))
+	}
+	return strings.Join(fields, ",")
+}
+
+// tagsOfDescription returns the tags as key=value, sorted by key
+func tagsOfDescription(path string, tags map[string]string) ([]string, error) {
+	keys := make([]string, 0, len(tags))
+	for key := range tags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var extraTags []string
+	for _, key := range keys {
+		tag := fmt.Sprintf("%v=%v", key, tags[key])
+		if !validateExtraTagsFormat([]string{tag}) {
+			return nil, fmt.Errorf("%v.%v: the tag '%v' does not have the syntax 'key=value'", path, key, tag)
+		}
+		extraTags = append(extraTags, tag)
+	}
+	return extraTags, nil
+}
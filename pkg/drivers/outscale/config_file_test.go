@@ -0,0 +1,150 @@
+package outscale
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/docker/machine/libmachine/drivers"
+	"github.com/stretchr/testify/assert"
+)
+
+const machineDescriptionYaml = `
+credentials:
+  accessKeyEnv: PROD_ACCESS_KEY
+  secretKeyEnv: PROD_SECRET_KEY
+region: eu-west-2
+vm:
+  type: tinav5.c4r8p1
+  omi: ami-12345678
+  userDataFile: cloud-init.yaml
+disks:
+  root:
+    type: io1
+    size: 50
+    iops: 3000
+  data:
+    - size: 100
+    - type: io1
+      snapshot: snap-12345678
+network:
+  subnet: subnet-1
+  privateIp: 10.0.1.10
+  securityGroupRules:
+    - protocol: tcp
+      fromPort: 8080
+      toPort: 8090
+      ipRange: 10.0.0.0/8
+  nics:
+    - subnet: subnet-2
+      securityGroups: [sg-1, sg-2]
+      deleteOnTermination: false
+tags:
+  all:
+    team: ops
+    env: prod
+  instances:
+    role: worker
+`
+
+func writeConfigFile(t *testing.T, name string, content string) string {
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestConfigFile(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("PROD_ACCESS_KEY", "ACCESS_KEY")
+	os.Setenv("PROD_SECRET_KEY", "SECRET_KEY")
+
+	configFile := writeConfigFile(t, "machine.yaml", machineDescriptionYaml)
+	assert.NoError(t, os.WriteFile(filepath.Join(filepath.Dir(configFile), "cloud-init.yaml"), []byte("#cloud-config\n"), 0600))
+
+	driver := NewDriver("machine", "")
+	assert.NoError(t, driver.SetConfigFromFlags(&drivers.CheckDriverOptions{
+		FlagsValues: map[string]interface{}{
+			flagConfigFile:   configFile,
+			flagInstanceType: "tinav5.c8r16p1",
+		},
+		CreateFlags: driver.GetCreateFlags(),
+	}))
+
+	assert.Equal(t, "ACCESS_KEY", driver.Ak)
+	assert.Equal(t, "SECRET_KEY", driver.Sk)
+	assert.Equal(t, "tinav5.c8r16p1", driver.InstanceType)
+	assert.Equal(t, "ami-12345678", driver.SourceOmi)
+	assert.Equal(t, "io1", driver.RootDiskType)
+	assert.Equal(t, int32(50), driver.RootDiskSize)
+	assert.Equal(t, int32(3000), driver.RootDiskIo1Iops)
+	assert.Equal(t, []string{"size=100", "type=io1,snapshot=snap-12345678"}, driver.DataDisks)
+	assert.Equal(t, "subnet-1", driver.SubnetId)
+	assert.Equal(t, "10.0.1.10", driver.PrivateIp)
+	assert.Equal(t, []string{"protocol=tcp,ports=8080-8090,ip-range=10.0.0.0/8"}, driver.SecurityGroupRules)
+	assert.Equal(t, []string{"subnet=subnet-2,sgs=sg-1:sg-2,delete-on-termination=false"}, driver.ExtraNics)
+	assert.Equal(t, []string{"env=prod", "team=ops"}, driver.ExtraTagsAll)
+	assert.Equal(t, []string{"role=worker"}, driver.ExtraTagsInstances)
+	assert.Equal(t, filepath.Join(filepath.Dir(configFile), "cloud-init.yaml"), driver.UserDataFile)
+	assert.Equal(t, "I2Nsb3VkLWNvbmZpZwo=", driver.userData)
+}
+
+func TestConfigFileJson(t *testing.T) {
+	os.Clearenv()
+
+	configFile := writeConfigFile(t, "machine.json", `{
+	"vm": {"type": "tinav5.c4r8p1"},
+	"disks": {"data": [{"size": 20, "type": "standard"}]}
+}`)
+
+	driver := NewDriver("machine", "")
+	assert.NoError(t, driver.SetConfigFromFlags(&drivers.CheckDriverOptions{
+		FlagsValues: map[string]interface{}{
+			flagAccessKey:  "ACCESS_KEY",
+			flagSecretKey:  "SECRET_KEY",
+			flagConfigFile: configFile,
+		},
+		CreateFlags: driver.GetCreateFlags(),
+	}))
+
+	assert.Equal(t, "tinav5.c4r8p1", driver.InstanceType)
+	assert.Equal(t, []string{"size=20,type=standard"}, driver.DataDisks)
+	assert.Equal(t, int32(defaultRootDiskSize), driver.RootDiskSize)
+}
+
+func TestConfigFileErrors(t *testing.T) {
+	os.Clearenv()
+
+	tests := []struct {
+		content string
+		err     string
+	}{
+		{"- vm", "the document is not accepted, it must be an object"},
+		{"vm:\n  typ: tinav5.c4r8p1", "vm.typ: the key is unknown (expected: 'type'|'omi'|'userDataFile'|'kubernetesNodeNameTag')"},
+		{"disks:\n  data:\n    - size: 10\n    - size: ten", "disks.data[1].size: the value is not accepted, it must be an integer"},
+		{"disks:\n  data:\n    - size: 10\n    - size: 10\n      iops: 100", "disks.data[1]: the iops can not be set for a 'gp2' volume"},
+		{"disks:\n  data:\n    - {}", "disks.data[0]: the disk is empty"},
+		{"disks:\n  root:\n    type: io1\n    size: 4\n    iops: 1500", "disks.root: the iops (1500) of a 'io1' volume is not accepted, it must be at most 300 per GiB (1200 for 4 GiB)"},
+		{"network:\n  nics:\n    - privateIp: 10.0.2.10", "network.nics[0]: the subnet is required"},
+		{"network:\n  securityGroupRules:\n    - protocol: icmp\n      fromPort: 1", "network.securityGroupRules[0]: the protocol 'icmp' is not accepted (expected: 'tcp'|'udp')"},
+		{"network:\n  secondaryPrivateIps: [10.0.1.11, 10.0.1]", "network.secondaryPrivateIps[1]: '10.0.1' is not a valid IPv4 address"},
+		{"tags:\n  all:\n    team: a=b", "tags.all.team: the tag 'team=a=b' does not have the syntax 'key=value'"},
+		{"credentials:\n  accessKeyEnv: MISSING_ACCESS_KEY", "credentials.accessKeyEnv: the environment variable 'MISSING_ACCESS_KEY' is not set"},
+		{"placement:\n  tenancy: host", "placement.tenancy: the tenancy is not accepted (got: host, expected: 'default'|'dedicated')"},
+	}
+
+	for _, test := range tests {
+		configFile := writeConfigFile(t, "machine.yaml", test.content)
+		_, err := configFileOptions(configFile, nil)
+		assert.EqualError(t, err, "the config file '"+configFile+"' is not valid: "+test.err, test.content)
+	}
+}
+
+func TestConfigFileVolumeTypes(t *testing.T) {
+	configFile := writeConfigFile(t, "machine.yaml", "disks:\n  data:\n    - size: 100\n      type: gp3\n      iops: 3000")
+
+	_, err := configFileOptions(configFile, nil)
+	assert.ErrorContains(t, err, "disks.data[0]: the type 'gp3' is not accepted")
+
+	_, err = configFileOptions(configFile, []string{"name=gp3,size=1-16384,iops=3000-16000,iops-per-gib=500"})
+	assert.NoError(t, err)
+}
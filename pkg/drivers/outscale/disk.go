@@ -0,0 +1,144 @@
+package outscale
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	osc "github.com/outscale/osc-sdk-go/v2"
+)
+
+const (
+	rootDiskDeviceName = "/dev/sda1"
+
+	// The data disks are attached from /dev/xvdb to /dev/xvdz
+	maxDataDisks = 25
+)
+
+// dataDiskSpec describes an additional volume of the VM, given as
+// size=<GiB>,type=<type>,iops=<iops>,snapshot=<id>
+type dataDiskSpec struct {
+	size       int32
+	diskType   string
+	iops       int32
+	snapshotId string
+}
+
+func parseDataDiskSpec(spec string, volumeTypes volumeTypeCatalog) (dataDiskSpec, error) {
+	disk := dataDiskSpec{
+		diskType: defaultRootDiskType,
+	}
+
+	for _, field := range strings.Split(spec, ",") {
+		splittedField := strings.SplitN(field, "=", 2)
+		if len(splittedField) != 2 {
+			return disk, fmt.Errorf("the field '%v' does not have the syntax 'key=value'", field)
+		}
+		key := strings.TrimSpace(splittedField[0])
+		value := strings.TrimSpace(splittedField[1])
+
+		switch key {
+		case "size":
+			size, err := strconv.ParseInt(value, 10, 32)
+			if err != nil || size <= 0 {
+				return disk, fmt.Errorf("the size '%v' is not accepted, it must be > 0", value)
+			}
+			disk.size = int32(size)
+		case "type":
+			disk.diskType = value
+		case "iops":
+			iops, err := strconv.ParseInt(value, 10, 32)
+			if err != nil || iops <= 0 {
+				return disk, fmt.Errorf("the iops '%v' is not accepted, it must be > 0", value)
+			}
+			disk.iops = int32(iops)
+		case "snapshot":
+			disk.snapshotId = value
+		default:
+			return disk, fmt.Errorf("the key '%v' is unknown (expected: 'size'|'type'|'iops'|'snapshot')", key)
+		}
+	}
+
+	if disk.size == 0 && disk.snapshotId == "" {
+		return disk, fmt.Errorf("the size is required when the volume is not created from a snapshot")
+	}
+
+	if volumeTypes[disk.diskType].maxIops > 0 && disk.iops == 0 {
+		disk.iops = defaultRootDiskIo1Iops
+	}
+
+	if err := volumeTypes.validate(disk.diskType, disk.size, disk.iops); err != nil {
+		return disk, err
+	}
+
+	return disk, nil
+}
+
+func parseDataDiskSpecs(specs []string, volumeTypes volumeTypeCatalog) ([]dataDiskSpec, error) {
+	if len(specs) > maxDataDisks {
+		return nil, fmt.Errorf("the number of data disks (%v) is not accepted, it must be at most %v", len(specs), maxDataDisks)
+	}
+
+	var disks []dataDiskSpec
+	for _, spec := range specs {
+		disk, err := parseDataDiskSpec(spec, volumeTypes)
+		if err != nil {
+			return nil, fmt.Errorf("the data disk '%v' is not valid: %v", spec, err)
+		}
+		disks = append(disks, disk)
+	}
+	return disks, nil
+}
+
+// dataDiskDeviceName returns the device of the n-th data disk: /dev/xvdb,
+// /dev/xvdc...
+func dataDiskDeviceName(index int) string {
+	return fmt.Sprintf("/dev/xvd%c", 'b'+index)
+}
+
+// blockDeviceMappings returns the root disk followed by the data disks. The
+// volumes can not be encrypted: BsuToCreate has no encryption nor KMS field.
+func (d *OscDriver) blockDeviceMappings() []osc.BlockDeviceMappingVmCreation {
+	rootDisk := osc.BlockDeviceMappingVmCreation{
+		Bsu: &osc.BsuToCreate{
+			VolumeType: osc.PtrString(d.RootDiskType),
+			VolumeSize: osc.PtrInt32(d.RootDiskSize),
+		},
+		DeviceName: osc.PtrString(rootDiskDeviceName),
+	}
+
+	if d.volumeTypes[d.RootDiskType].maxIops > 0 {
+		rootDisk.Bsu.SetIops(d.RootDiskIo1Iops)
+	}
+
+	if d.RootDiskSnapshotId != "" {
+		rootDisk.Bsu.SetSnapshotId(d.RootDiskSnapshotId)
+	}
+
+	mappings := []osc.BlockDeviceMappingVmCreation{rootDisk}
+	for i, disk := range d.dataDisks {
+		bsu := osc.BsuToCreate{
+			VolumeType:         osc.PtrString(disk.diskType),
+			DeleteOnVmDeletion: osc.PtrBool(true),
+		}
+
+		if disk.size > 0 {
+			bsu.SetVolumeSize(disk.size)
+		}
+
+		if disk.iops > 0 {
+			bsu.SetIops(disk.iops)
+		}
+
+		if disk.snapshotId != "" {
+			bsu.SetSnapshotId(disk.snapshotId)
+		}
+
+		mappings = append(mappings, osc.BlockDeviceMappingVmCreation{
+			Bsu:        &bsu,
+			DeviceName: osc.PtrString(dataDiskDeviceName(i)),
+		})
+	}
+
+	return mappings
+}
@@ -0,0 +1,90 @@
+package outscale
+
+import (
+	"testing"
+
+	osc "github.com/outscale/osc-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDataDiskSpec(t *testing.T) {
+	disk, err := parseDataDiskSpec("size=100,type=io1,iops=3000,snapshot=snap-1", defaultVolumeTypes)
+	assert.NoError(t, err)
+	assert.Equal(t, dataDiskSpec{size: 100, diskType: "io1", iops: 3000, snapshotId: "snap-1"}, disk)
+
+	disk, err = parseDataDiskSpec("size=10", defaultVolumeTypes)
+	assert.NoError(t, err)
+	assert.Equal(t, dataDiskSpec{size: 10, diskType: "gp2"}, disk)
+
+	disk, err = parseDataDiskSpec("snapshot=snap-1,type=io1", defaultVolumeTypes)
+	assert.NoError(t, err)
+	assert.Equal(t, dataDiskSpec{diskType: "io1", iops: defaultRootDiskIo1Iops, snapshotId: "snap-1"}, disk)
+
+	errors := map[string]string{
+		"type=gp2":           "the size is required when the volume is not created from a snapshot",
+		"size=0":             "the size '0' is not accepted, it must be > 0",
+		"size=10,type=ssd":   "the type 'ssd' is not accepted (expected: 'gp2'|'io1'|'standard')",
+		"size=10,iops=100":   "the iops can not be set for a 'gp2' volume",
+		"size=4,type=io1":    "the iops (1500) of a 'io1' volume is not accepted, it must be at most 300 per GiB (1200 for 4 GiB)",
+		"size=10,encrypted":  "the field 'encrypted' does not have the syntax 'key=value'",
+		"size=10,device=sdb": "the key 'device' is unknown (expected: 'size'|'type'|'iops'|'snapshot')",
+	}
+	for spec, expected := range errors {
+		_, err := parseDataDiskSpec(spec, defaultVolumeTypes)
+		assert.EqualError(t, err, expected, spec)
+	}
+}
+
+func TestParseDataDiskSpecs(t *testing.T) {
+	specs := make([]string, maxDataDisks)
+	for i := range specs {
+		specs[i] = "size=10"
+	}
+
+	disks, err := parseDataDiskSpecs(specs, defaultVolumeTypes)
+	assert.NoError(t, err)
+	assert.Len(t, disks, maxDataDisks)
+	assert.Equal(t, "/dev/xvdz", dataDiskDeviceName(len(disks)-1))
+
+	_, err = parseDataDiskSpecs(append(specs, "size=10"), defaultVolumeTypes)
+	assert.EqualError(t, err, "the number of data disks (26) is not accepted, it must be at most 25")
+}
+
+func TestBlockDeviceMappings(t *testing.T) {
+	d := NewDriver("test", "")
+	d.RootDiskType = "gp2"
+	d.RootDiskSize = 20
+	d.RootDiskSnapshotId = "snap-root"
+	d.dataDisks = []dataDiskSpec{
+		{size: 100, diskType: "io1", iops: 3000},
+		{diskType: "standard", snapshotId: "snap-data"},
+	}
+
+	assert.Equal(t, []osc.BlockDeviceMappingVmCreation{
+		{
+			DeviceName: osc.PtrString("/dev/sda1"),
+			Bsu: &osc.BsuToCreate{
+				VolumeType: osc.PtrString("gp2"),
+				VolumeSize: osc.PtrInt32(20),
+				SnapshotId: osc.PtrString("snap-root"),
+			},
+		},
+		{
+			DeviceName: osc.PtrString("/dev/xvdb"),
+			Bsu: &osc.BsuToCreate{
+				VolumeType:         osc.PtrString("io1"),
+				VolumeSize:         osc.PtrInt32(100),
+				Iops:               osc.PtrInt32(3000),
+				DeleteOnVmDeletion: osc.PtrBool(true),
+			},
+		},
+		{
+			DeviceName: osc.PtrString("/dev/xvdc"),
+			Bsu: &osc.BsuToCreate{
+				VolumeType:         osc.PtrString("standard"),
+				SnapshotId:         osc.PtrString("snap-data"),
+				DeleteOnVmDeletion: osc.PtrBool(true),
+			},
+		},
+	}, d.blockDeviceMappings())
+}
@@ -0,0 +1,254 @@
+package outscale
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	osc "github.com/outscale/osc-sdk-go/v2"
+)
+
+// Sentinel errors matching the known errors of the OUTSCALE API. They can be
+// tested with errors.Is on any error returned by the driver.
+var (
+	ErrInvalidCredentials  = errors.New("invalid credentials")
+	ErrAccessDenied        = errors.New("access denied")
+	ErrQuotaExceeded       = errors.New("quota exceeded")
+	ErrResourceNotFound    = errors.New("resource not found")
+	ErrInvalidParameter    = errors.New("invalid parameter")
+	ErrDependencyViolation = errors.New("resource in use")
+	ErrThrottled           = errors.New("request throttled")
+)
+
+// APIError is one of the errors listed in an error response of the API
+type APIError struct {
+	Code    string
+	Type    string
+	Details string
+}
+
+// CloudError is the error returned when a call to the OUTSCALE API fails
+type CloudError struct {
+	httpRes    *http.Response
+	cloudError error
+	apiError   *osc.ErrorResponse
+}
+
+func (e CloudError) Error() string {
+	return formatError(e.cloudError, e.httpRes, e.apiError)
+}
+
+// Unwrap returns the error returned by the SDK
+func (e CloudError) Unwrap() error {
+	return e.cloudError
+}
+
+// StatusCode returns the HTTP status of the response, or 0 if the API has not
+// been reached
+func (e CloudError) StatusCode() int {
+	if e.httpRes == nil {
+		return 0
+	}
+	return e.httpRes.StatusCode
+}
+
+// RequestId returns the id of the request given by the API, if any
+func (e CloudError) RequestId() string {
+	if e.apiError == nil || !e.apiError.HasResponseContext() {
+		return ""
+	}
+	return e.apiError.ResponseContext.GetRequestId()
+}
+
+// Errors returns all the errors listed in the response of the API
+func (e CloudError) Errors() []APIError {
+	if e.apiError == nil {
+		return nil
+	}
+
+	var apiErrors []APIError
+	for _, apiError := range e.apiError.GetErrors() {
+		apiErrors = append(apiErrors, APIError{
+			Code:    apiError.GetCode(),
+			Type:    apiError.GetType(),
+			Details: apiError.GetDetails(),
+		})
+	}
+	return apiErrors
+}
+
+// errorCodeRanges maps the categories of the documented error codes of the
+// OUTSCALE API (https://docs.outscale.com/api#errors) to the sentinel errors
+var errorCodeRanges = []struct {
+	first, last int
+	sentinel    error
+}{
+	{1, 999, ErrAccessDenied},            // AccessDenied, AuthFailure
+	{3000, 3999, ErrInvalidParameter},    // InvalidParameter
+	{4000, 4999, ErrInvalidParameter},    // InvalidParameterValue
+	{5000, 5999, ErrResourceNotFound},    // InvalidResource
+	{7000, 7999, ErrInvalidParameter},    // MissingParameter
+	{9000, 9999, ErrDependencyViolation}, // ResourceConflict
+	{10000, 10999, ErrQuotaExceeded},     // TooManyResources
+}
+
+// Is reports whether the error matches one of the sentinel errors. The codes
+// of the errors listed in the response decide, the HTTP status is only used
+// when none of them is a known code.
+func (e CloudError) Is(target error) bool {
+	switch target {
+	case ErrInvalidCredentials:
+		return e.StatusCode() == http.StatusUnauthorized
+	case ErrThrottled:
+		for _, statusCode := range ThrottlingErrors {
+			if e.StatusCode() == statusCode {
+				return true
+			}
+		}
+		return false
+	}
+
+	if sentinels := e.codeSentinels(); len(sentinels) > 0 {
+		for _, sentinel := range sentinels {
+			if sentinel == target {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch target {
+	case ErrAccessDenied:
+		return e.StatusCode() == http.StatusForbidden
+	case ErrResourceNotFound:
+		return e.StatusCode() == http.StatusNotFound
+	case ErrDependencyViolation:
+		return e.StatusCode() == http.StatusConflict
+	}
+	return false
+}
+
+// codeSentinels returns the sentinel errors matching the known codes of the
+// errors of the response
+func (e CloudError) codeSentinels() []error {
+	var sentinels []error
+	for _, apiError := range e.Errors() {
+		code, err := strconv.Atoi(apiError.Code)
+		if err != nil {
+			continue
+		}
+		for _, codeRange := range errorCodeRanges {
+			if code >= codeRange.first && code <= codeRange.last {
+				sentinels = append(sentinels, codeRange.sentinel)
+			}
+		}
+	}
+	return sentinels
+}
+
+// MultiError gathers the errors of an operation which goes on after a failure
+type MultiError []error
+
+func (e MultiError) Error() string {
+	messages := make([]string, 0, len(e))
+	for _, err := range e {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Is reports whether one of the errors matches the target
+func (e MultiError) Is(target error) bool {
+	for _, err := range e {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first error matching the target
+func (e MultiError) As(target interface{}) bool {
+	for _, err := range e {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrorOrNil returns nil when no error has been gathered
+func (e MultiError) ErrorOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+func extractApiError(err error) (bool, *osc.ErrorResponse) {
+	genericError, ok := err.(osc.GenericOpenAPIError)
+	if ok {
+		errorsResponse, ok := genericError.Model().(osc.ErrorResponse)
+		if ok {
+			return true, &errorsResponse
+		}
+		return false, nil
+	}
+	return false, nil
+}
+
+// formatError renders the HTTP status, every error listed by the API and the
+// id of the request. Each part is optional since a transport error has no
+// response and not all the error responses have a body.
+func formatError(err error, httpRes *http.Response, apiError *osc.ErrorResponse) string {
+	var parts []string
+
+	if httpRes != nil {
+		status := httpRes.Status
+		if status == "" {
+			status = fmt.Sprintf("%d %s", httpRes.StatusCode, http.StatusText(httpRes.StatusCode))
+		}
+		parts = append(parts, status)
+	}
+
+	requestId := ""
+	if apiError != nil {
+		for _, e := range apiError.GetErrors() {
+			parts = append(parts, fmt.Sprintf("'%v %v' - '%v'", e.GetCode(), e.GetType(), e.GetDetails()))
+		}
+
+		if apiError.HasResponseContext() {
+			requestId = apiError.ResponseContext.GetRequestId()
+		}
+	}
+
+	if len(parts) == 0 {
+		if err == nil {
+			return "unknown error"
+		}
+		parts = append(parts, err.Error())
+	}
+
+	message := strings.Join(parts, " - ")
+	if requestId != "" {
+		message = fmt.Sprintf("%s (request id: %s)", message, requestId)
+	}
+
+	return message
+}
+
+func wrapError(err error, httpRes *http.Response) error {
+	if err == nil {
+		return nil
+	}
+
+	_, apiError := extractApiError(err)
+
+	return CloudError{
+		httpRes:    httpRes,
+		cloudError: err,
+		apiError:   apiError,
+	}
+}
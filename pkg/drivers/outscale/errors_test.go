@@ -0,0 +1,210 @@
+package outscale
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	osc "github.com/outscale/osc-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newApiErrorResponse(requestId string, apiErrors ...APIError) *osc.ErrorResponse {
+	var errorList []osc.Errors
+	for _, apiError := range apiErrors {
+		errorList = append(errorList, osc.Errors{
+			Code:    osc.PtrString(apiError.Code),
+			Type:    osc.PtrString(apiError.Type),
+			Details: osc.PtrString(apiError.Details),
+		})
+	}
+
+	response := osc.NewErrorResponse()
+	response.SetErrors(errorList)
+	response.SetResponseContext(osc.ResponseContext{RequestId: osc.PtrString(requestId)})
+	return response
+}
+
+func TestCloudErrorAccessors(t *testing.T) {
+	err := CloudError{
+		httpRes:    &http.Response{StatusCode: 400, Status: "400 Bad Request"},
+		cloudError: errors.New("400 Bad Request"),
+		apiError: newApiErrorResponse("0123-4567",
+			APIError{Code: "4045", Type: "InvalidParameterValue", Details: "The VmType is invalid."},
+		),
+	}
+
+	assert.Equal(t, 400, err.StatusCode())
+	assert.Equal(t, "0123-4567", err.RequestId())
+	assert.Equal(t, []APIError{{Code: "4045", Type: "InvalidParameterValue", Details: "The VmType is invalid."}}, err.Errors())
+
+	assert.Equal(t, 0, CloudError{cloudError: errors.New("connection refused")}.StatusCode())
+	assert.Empty(t, CloudError{cloudError: errors.New("connection refused")}.RequestId())
+}
+
+func TestCloudErrorSentinels(t *testing.T) {
+	cases := []struct {
+		err      CloudError
+		expected error
+	}{
+		{
+			err:      CloudError{httpRes: &http.Response{StatusCode: 401}},
+			expected: ErrInvalidCredentials,
+		},
+		{
+			err:      CloudError{httpRes: &http.Response{StatusCode: 400}, apiError: newApiErrorResponse("", APIError{Code: "4", Type: "AccessDenied"})},
+			expected: ErrAccessDenied,
+		},
+		{
+			err:      CloudError{httpRes: &http.Response{StatusCode: 400}, apiError: newApiErrorResponse("", APIError{Code: "10022", Type: "TooManyResources (QuotaExceded)"})},
+			expected: ErrQuotaExceeded,
+		},
+		{
+			err:      CloudError{httpRes: &http.Response{StatusCode: 400}, apiError: newApiErrorResponse("", APIError{Code: "5063", Type: "InvalidResource"})},
+			expected: ErrResourceNotFound,
+		},
+		{
+			err:      CloudError{httpRes: &http.Response{StatusCode: 400}, apiError: newApiErrorResponse("", APIError{Code: "7000", Type: "MissingParameter"})},
+			expected: ErrInvalidParameter,
+		},
+		{
+			err:      CloudError{httpRes: &http.Response{StatusCode: 400}, apiError: newApiErrorResponse("", APIError{Code: "9085", Type: "ResourceConflict"})},
+			expected: ErrDependencyViolation,
+		},
+		{
+			err:      CloudError{httpRes: &http.Response{StatusCode: 503}},
+			expected: ErrThrottled,
+		},
+		// The code decides over the type and the status
+		{
+			err:      CloudError{httpRes: &http.Response{StatusCode: 409}, apiError: newApiErrorResponse("", APIError{Code: "5071", Type: "Unknown"})},
+			expected: ErrResourceNotFound,
+		},
+		{
+			err:      CloudError{httpRes: &http.Response{StatusCode: 404}, apiError: newApiErrorResponse("", APIError{Code: "4045", Type: "InvalidResource"})},
+			expected: ErrInvalidParameter,
+		},
+		// Without a known code, the status decides
+		{
+			err:      CloudError{httpRes: &http.Response{StatusCode: 403}},
+			expected: ErrAccessDenied,
+		},
+		{
+			err:      CloudError{httpRes: &http.Response{StatusCode: 404}, apiError: newApiErrorResponse("", APIError{Code: "", Type: "TooManyResources"})},
+			expected: ErrResourceNotFound,
+		},
+		{
+			err:      CloudError{httpRes: &http.Response{StatusCode: 409}, apiError: newApiErrorResponse("", APIError{Code: "6031", Type: "InvalidState"})},
+			expected: ErrDependencyViolation,
+		},
+		{
+			err:      CloudError{httpRes: &http.Response{StatusCode: 400}, apiError: newApiErrorResponse("", APIError{Code: "", Type: "InvalidResource"})},
+			expected: nil,
+		},
+	}
+
+	sentinels := []error{ErrInvalidCredentials, ErrAccessDenied, ErrQuotaExceeded, ErrResourceNotFound, ErrInvalidParameter, ErrDependencyViolation, ErrThrottled}
+
+	for _, c := range cases {
+		// The errors returned by the driver wrap the CloudError
+		wrapped := fmt.Errorf("Error while submitting the request: %w", c.err)
+
+		for _, sentinel := range sentinels {
+			assert.Equalf(t, sentinel == c.expected, errors.Is(wrapped, sentinel), "errors.Is(%v) is not the one expected for the status %v", sentinel, c.err.StatusCode())
+		}
+
+		var cloudError CloudError
+		assert.True(t, errors.As(wrapped, &cloudError))
+		assert.Equal(t, c.err.StatusCode(), cloudError.StatusCode())
+	}
+}
+
+func TestFormatError(t *testing.T) {
+	cases := []struct {
+		name     string
+		err      error
+		httpRes  *http.Response
+		apiError *osc.ErrorResponse
+		expected string
+	}{
+		{
+			name:     "transport error",
+			err:      errors.New("dial tcp: connection refused"),
+			expected: "dial tcp: connection refused",
+		},
+		{
+			name:     "transport error with an api model",
+			err:      errors.New("unexpected EOF"),
+			apiError: newApiErrorResponse("0123", APIError{Code: "4045", Type: "InvalidParameterValue", Details: "The VmType is invalid."}),
+			expected: "'4045 InvalidParameterValue' - 'The VmType is invalid.' (request id: 0123)",
+		},
+		{
+			name:     "response without body",
+			err:      errors.New("503 Service Unavailable"),
+			httpRes:  &http.Response{StatusCode: 503, Status: "503 Service Unavailable"},
+			expected: "503 Service Unavailable",
+		},
+		{
+			name:     "response without status text",
+			httpRes:  &http.Response{StatusCode: 429},
+			expected: "429 Too Many Requests",
+		},
+		{
+			name:     "single error",
+			httpRes:  &http.Response{StatusCode: 400, Status: "400 Bad Request"},
+			apiError: newApiErrorResponse("0123", APIError{Code: "5063", Type: "InvalidResource", Details: "The VmId doesn't exist."}),
+			expected: "400 Bad Request - '5063 InvalidResource' - 'The VmId doesn't exist.' (request id: 0123)",
+		},
+		{
+			name:    "multiple errors",
+			httpRes: &http.Response{StatusCode: 400, Status: "400 Bad Request"},
+			apiError: newApiErrorResponse("0123",
+				APIError{Code: "4045", Type: "InvalidParameterValue", Details: "The VmType is invalid."},
+				APIError{Code: "7000", Type: "MissingParameter", Details: "The ImageId is missing."},
+			),
+			expected: "400 Bad Request - '4045 InvalidParameterValue' - 'The VmType is invalid.' - '7000 MissingParameter' - 'The ImageId is missing.' (request id: 0123)",
+		},
+		{
+			name:     "error response without errors nor request id",
+			httpRes:  &http.Response{StatusCode: 500, Status: "500 Internal Server Error"},
+			apiError: osc.NewErrorResponse(),
+			expected: "500 Internal Server Error",
+		},
+		{
+			name:     "nothing",
+			expected: "unknown error",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			assert.Equal(t, c.expected, formatError(c.err, c.httpRes, c.apiError))
+			assert.Equal(t, c.expected, CloudError{httpRes: c.httpRes, cloudError: c.err, apiError: c.apiError}.Error())
+		})
+	}
+}
+
+func TestWrapError(t *testing.T) {
+	assert.NoError(t, wrapError(nil, nil))
+
+	err := wrapError(errors.New("connection reset by peer"), nil)
+	assert.Equal(t, "connection reset by peer", err.Error())
+}
+
+func TestMultiError(t *testing.T) {
+	var errs MultiError
+	assert.NoError(t, errs.ErrorOrNil())
+
+	notFound := CloudError{httpRes: &http.Response{StatusCode: 400, Status: "400 Bad Request"}, apiError: newApiErrorResponse("", APIError{Code: "5063", Type: "InvalidResource"})}
+	errs = append(errs, errors.New("first error"), fmt.Errorf("second error: %w", notFound))
+
+	err := errs.ErrorOrNil()
+	assert.Error(t, err)
+	assert.Equal(t, "first error; second error: 400 Bad Request - '5063 InvalidResource' - ''", err.Error())
+	assert.True(t, errors.Is(err, ErrResourceNotFound))
+	assert.False(t, errors.Is(err, ErrQuotaExceeded))
+
+	var cloudError CloudError
+	assert.True(t, errors.As(err, &cloudError))
+}
@@ -0,0 +1,147 @@
+package outscale
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sync"
+	"testing"
+
+	osc "github.com/outscale/osc-sdk-go/v2"
+)
+
+// fakeApiHandler answers an operation with a status and a body
+type fakeApiHandler func(request map[string]interface{}) (int, interface{})
+
+// fakeApi is an OUTSCALE API answering with the handlers of the operations
+// and recording the operations called
+type fakeApi struct {
+	t        *testing.T
+	mutex    sync.Mutex
+	handlers map[string]fakeApiHandler
+	calls    []string
+}
+
+// newFakeApiDriver returns a driver calling a fake API. The calls are not
+// retried.
+func newFakeApiDriver(t *testing.T, machineName string, handlers map[string]fakeApiHandler) (*OscDriver, *fakeApi) {
+	api, client := newFakeApi(t, handlers)
+
+	d := NewDriver(machineName, t.TempDir())
+	d.oscApi = client
+
+	return d, api
+}
+
+// newFakeApi returns a fake API and a client calling it
+func newFakeApi(t *testing.T, handlers map[string]fakeApiHandler) (*fakeApi, *OscApiData) {
+	api := &fakeApi{
+		t:        t,
+		handlers: handlers,
+	}
+
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	config := osc.NewConfiguration()
+	config.Servers = osc.ServerConfigurations{{URL: server.URL + "/api/v1"}}
+
+	ctx := context.WithValue(context.Background(), osc.ContextAWSv4, osc.AWSv4{
+		AccessKey: "ACCESS_KEY",
+		SecretKey: "SECRET_KEY",
+	})
+	ctx = context.WithValue(ctx, osc.ContextServerIndex, 0)
+
+	return api, &OscApiData{
+		client:       osc.NewAPIClient(config),
+		context:      ctx,
+		retryOptions: retryPolicy{maxAttempts: 1}.options(),
+	}
+}
+
+// useFakeApiInCommands makes the machine commands call a fake API until the
+// end of the test
+func useFakeApiInCommands(t *testing.T, handlers map[string]fakeApiHandler) *fakeApi {
+	api, client := newFakeApi(t, handlers)
+
+	t.Cleanup(func() { loadCommandMachine = loadMachine })
+	loadCommandMachine = func(storePath string, machineName string) (*OscDriver, error) {
+		d, err := loadMachine(storePath, machineName)
+		if d != nil {
+			d.oscApi = client
+		}
+		return d, err
+	}
+
+	return api
+}
+
+func (a *fakeApi) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	operation := path.Base(r.URL.Path)
+
+	var request map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		a.t.Errorf("The request of %v is not valid: %v", operation, err)
+	}
+
+	a.mutex.Lock()
+	a.calls = append(a.calls, operation)
+	handler, ok := a.handlers[operation]
+	a.mutex.Unlock()
+
+	status, body := http.StatusInternalServerError, interface{}(fakeApiError("InternalError"))
+	if ok {
+		status, body = handler(request)
+	} else {
+		a.t.Errorf("Unexpected call to %v", operation)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		a.t.Errorf("The response of %v is not valid: %v", operation, err)
+	}
+}
+
+// called returns the operations called, in order
+func (a *fakeApi) called() []string {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return append([]string(nil), a.calls...)
+}
+
+func fakeApiError(errorType string) osc.ErrorResponse {
+	return osc.ErrorResponse{
+		Errors: &[]osc.Errors{{Type: osc.PtrString(errorType)}},
+	}
+}
+
+// fakeApiOk answers every call with the same body
+func fakeApiOk(body interface{}) fakeApiHandler {
+	return func(map[string]interface{}) (int, interface{}) {
+		return http.StatusOK, body
+	}
+}
+
+// fakeVm is a VM whose state follows the calls to start and stop it
+type fakeVm struct {
+	mutex sync.Mutex
+	vm    osc.Vm
+}
+
+func (v *fakeVm) read(map[string]interface{}) (int, interface{}) {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	return http.StatusOK, osc.ReadVmsResponse{Vms: &[]osc.Vm{v.vm}}
+}
+
+func (v *fakeVm) setState(state string) fakeApiHandler {
+	return func(map[string]interface{}) (int, interface{}) {
+		v.mutex.Lock()
+		defer v.mutex.Unlock()
+		v.vm.SetState(state)
+		return http.StatusOK, struct{}{}
+	}
+}
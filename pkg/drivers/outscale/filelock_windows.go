@@ -0,0 +1,16 @@
+//go:build windows
+
+package outscale
+
+import (
+	"errors"
+	"os"
+)
+
+func lockFile(file *os.File) error {
+	return errors.New("sharing the rate limit between processes is not supported on Windows")
+}
+
+func unlockFile(file *os.File) error {
+	return nil
+}
@@ -0,0 +1,115 @@
+package outscale
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/docker/machine/libmachine/drivers"
+	"github.com/docker/machine/libmachine/log"
+	"github.com/docker/machine/libmachine/state"
+	osc "github.com/outscale/osc-sdk-go/v2"
+)
+
+// GrowVolume grows a volume of the running machine, then its partition and its
+// file system over SSH. An empty deviceName selects the root volume, mounted
+// on '/', otherwise mountPoint is where the file system of the volume is
+// mounted.
+func (d *OscDriver) GrowVolume(deviceName string, size int32, mountPoint string) error {
+	// The file system is grown over SSH
+	vmState, err := d.GetState()
+	if err != nil {
+		return err
+	}
+
+	if vmState != state.Running {
+		return fmt.Errorf("The VM '%v' is not running (state: '%v'), start it to grow its volumes", d.VmId, vmState)
+	}
+
+	vms, err := readVms(d, osc.FiltersVm{
+		VmIds: &[]string{d.VmId},
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(vms) == 0 {
+		return fmt.Errorf("The VM '%v' has not been found", d.VmId)
+	}
+	vm := vms[0]
+
+	isRoot := deviceName == "" || deviceName == vm.GetRootDeviceName()
+	if isRoot {
+		deviceName = vm.GetRootDeviceName()
+		mountPoint = "/"
+	}
+
+	if mountPoint == "" {
+		return fmt.Errorf("the mount point of the volume '%v' is required to grow its file system", deviceName)
+	}
+
+	volumeId, err := volumeIdOfDevice(vm, deviceName)
+	if err != nil {
+		return err
+	}
+
+	volumes, err := readVolumes(d, osc.FiltersVolume{
+		VolumeIds: &[]string{volumeId},
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(volumes) == 0 {
+		return fmt.Errorf("The Volume '%v' has not been found", volumeId)
+	}
+
+	if size < volumes[0].GetSize() {
+		return fmt.Errorf("the volume can not be shrunk (current size: %v, requested: %v)", volumes[0].GetSize(), size)
+	}
+
+	if size > volumes[0].GetSize() {
+		if err := d.validateVolumeSize(volumes[0], size); err != nil {
+			return err
+		}
+
+		log.Infof("Growing the volume '%v' (%v) to %v GiB", volumeId, deviceName, size)
+		if err := updateVolumeSize(d, volumeId, size); err != nil {
+			return err
+		}
+	}
+
+	if isRoot {
+		d.RootDiskSize = size
+	}
+
+	log.Infof("Growing the file system mounted on '%v'", mountPoint)
+	output, err := drivers.RunSSHCommandFromDriver(d, growFileSystemCommand(mountPoint))
+	if err != nil {
+		return fmt.Errorf("Error while growing the file system mounted on '%v': %w\n%v", mountPoint, err, output)
+	}
+	log.Debugf("Output of the file system growth: %v", output)
+
+	return nil
+}
+
+// growFileSystemCommand returns the shell script growing the partition, if
+// any, and the file system mounted on mountPoint to the size of their volume.
+// growpart exits with 1 when the partition already fills the volume.
+func growFileSystemCommand(mountPoint string) string {
+	quotedMountPoint := shellQuote(mountPoint)
+
+	return strings.Join([]string{
+		"set -e",
+		fmt.Sprintf("source=$(findmnt -n -o SOURCE %s)", quotedMountPoint),
+		fmt.Sprintf("fstype=$(findmnt -n -o FSTYPE %s)", quotedMountPoint),
+		`name=$(basename "$source")`,
+		`if [ -e "/sys/class/block/$name/partition" ]; then`,
+		`  sudo growpart "/dev/$(lsblk -n -o PKNAME "$source")" "$(cat "/sys/class/block/$name/partition")" || [ $? -eq 1 ]`,
+		`fi`,
+		`case "$fstype" in`,
+		`  ext2|ext3|ext4) sudo resize2fs "$source" ;;`,
+		fmt.Sprintf("  xfs) sudo xfs_growfs %s ;;", quotedMountPoint),
+		`  *) echo "The file system $fstype can not be grown" >&2; exit 1 ;;`,
+		`esac`,
+	}, "\n")
+}
@@ -0,0 +1,108 @@
+package outscale
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/docker/machine/libmachine/ssh"
+	osc "github.com/outscale/osc-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// growApiHandlers returns the handlers of a VM with a 10 GiB root volume and
+// a 50 GiB data volume attached as /dev/xvdb
+func growApiHandlers(sizes map[string]int32) map[string]fakeApiHandler {
+	var mutex sync.Mutex
+	vm := &fakeVm{vm: osc.Vm{
+		VmId:           osc.PtrString("i-12345678"),
+		State:          osc.PtrString("running"),
+		RootDeviceName: osc.PtrString("/dev/sda1"),
+		BlockDeviceMappings: &[]osc.BlockDeviceMappingCreated{
+			{DeviceName: osc.PtrString("/dev/sda1"), Bsu: &osc.BsuCreated{VolumeId: osc.PtrString("vol-root")}},
+			{DeviceName: osc.PtrString("/dev/xvdb"), Bsu: &osc.BsuCreated{VolumeId: osc.PtrString("vol-data")}},
+		},
+	}}
+
+	return map[string]fakeApiHandler{
+		"ReadVms": vm.read,
+		"ReadVolumes": func(request map[string]interface{}) (int, interface{}) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			volumeId := request["Filters"].(map[string]interface{})["VolumeIds"].([]interface{})[0].(string)
+			return http.StatusOK, osc.ReadVolumesResponse{Volumes: &[]osc.Volume{{
+				VolumeId:   osc.PtrString(volumeId),
+				VolumeType: osc.PtrString("gp2"),
+				Size:       osc.PtrInt32(sizes[volumeId]),
+			}}}
+		},
+		"UpdateVolume": func(request map[string]interface{}) (int, interface{}) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			volumeId := request["VolumeId"].(string)
+			sizes[volumeId] = int32(request["Size"].(float64))
+			return http.StatusOK, osc.UpdateVolumeResponse{Volume: &osc.Volume{VolumeId: osc.PtrString(volumeId)}}
+		},
+	}
+}
+
+func TestGrowVolume(t *testing.T) {
+	// The SSH key of the machine does not exist, so that the growth of the
+	// file system fails without connecting
+	ssh.SetDefaultClient(ssh.Native)
+	defer ssh.SetDefaultClient(ssh.External)
+
+	sizes := map[string]int32{"vol-root": 10, "vol-data": 50}
+	driver, api := newFakeApiDriver(t, "node1", growApiHandlers(sizes))
+	driver.VmId = "i-12345678"
+	driver.IPAddress = "192.0.2.1"
+
+	err := driver.GrowVolume("", 20, "")
+	assert.ErrorContains(t, err, "Error while growing the file system mounted on '/'")
+	assert.Equal(t, int32(20), sizes["vol-root"])
+	assert.Equal(t, int32(20), driver.RootDiskSize)
+	assert.Contains(t, api.called(), "UpdateVolume")
+
+	err = driver.GrowVolume("/dev/xvdb", 80, "/var/lib/docker")
+	assert.ErrorContains(t, err, "Error while growing the file system mounted on '/var/lib/docker'")
+	assert.Equal(t, int32(80), sizes["vol-data"])
+	assert.Equal(t, int32(20), driver.RootDiskSize)
+}
+
+func TestGrowVolumeErrors(t *testing.T) {
+	sizes := map[string]int32{"vol-root": 10, "vol-data": 50}
+	driver, api := newFakeApiDriver(t, "node1", growApiHandlers(sizes))
+	driver.VmId = "i-12345678"
+
+	assert.EqualError(t, driver.GrowVolume("", 5, ""), "the volume can not be shrunk (current size: 10, requested: 5)")
+	assert.EqualError(t, driver.GrowVolume("/dev/xvdb", 80, ""), "the mount point of the volume '/dev/xvdb' is required to grow its file system")
+	assert.EqualError(t, driver.GrowVolume("/dev/xvdc", 80, "/data"), "No volume is attached to the VM 'i-12345678' as '/dev/xvdc'")
+	assert.ErrorContains(t, driver.GrowVolume("", 20000, ""), "20000")
+
+	assert.NotContains(t, api.called(), "UpdateVolume")
+	assert.Equal(t, int32(10), sizes["vol-root"])
+}
+
+func TestGrowVolumeStoppedVm(t *testing.T) {
+	sizes := map[string]int32{"vol-root": 10, "vol-data": 50}
+	handlers := growApiHandlers(sizes)
+	vm := &fakeVm{vm: osc.Vm{VmId: osc.PtrString("i-12345678"), State: osc.PtrString("stopped")}}
+	handlers["ReadVms"] = vm.read
+	driver, api := newFakeApiDriver(t, "node1", handlers)
+	driver.VmId = "i-12345678"
+
+	assert.EqualError(t, driver.GrowVolume("", 20, ""), "The VM 'i-12345678' is not running (state: 'Stopped'), start it to grow its volumes")
+	assert.NotContains(t, api.called(), "UpdateVolume")
+	assert.Equal(t, int32(10), sizes["vol-root"])
+}
+
+func TestGrowFileSystemCommand(t *testing.T) {
+	command := growFileSystemCommand("/var/lib/docker")
+	assert.Contains(t, command, "source=$(findmnt -n -o SOURCE /var/lib/docker)")
+	assert.Contains(t, command, `sudo growpart "/dev/$(lsblk -n -o PKNAME "$source")"`)
+	assert.Contains(t, command, `ext2|ext3|ext4) sudo resize2fs "$source" ;;`)
+	assert.Contains(t, command, "xfs) sudo xfs_growfs /var/lib/docker ;;")
+
+	// The mount point is quoted for the shell
+	assert.Contains(t, growFileSystemCommand("/mnt/it's"), `findmnt -n -o SOURCE '/mnt/it'\''s'`)
+}
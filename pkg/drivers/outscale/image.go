@@ -0,0 +1,176 @@
+package outscale
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	retry "github.com/avast/retry-go"
+	"github.com/docker/machine/libmachine/log"
+	"github.com/docker/machine/libmachine/state"
+	osc "github.com/outscale/osc-sdk-go/v2"
+)
+
+const (
+	// The creation of an OMI snapshots all the volumes of the VM
+	defaultImageDelay   = time.Duration(10) * time.Second
+	defaultImageTimeout = time.Duration(60) * time.Minute
+)
+
+func readImages(d *OscDriver, filters osc.FiltersImage) ([]osc.Image, error) {
+	oscApi, err := d.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	request := osc.ReadImagesRequest{
+		Filters: &filters,
+	}
+
+	var httpRes *http.Response
+	var response osc.ReadImagesResponse
+	err = retry.Do(
+		func() error {
+			var response_error error
+			response, httpRes, response_error = oscApi.client.ImageApi.ReadImages(oscApi.context).ReadImagesRequest(request).Execute()
+			return wrapError(response_error, httpRes)
+		},
+		oscApi.retryOptions...,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error while submitting the Image read request: %w", err)
+	}
+
+	return response.GetImages(), nil
+}
+
+// defaultImageName returns the name of an OMI created from the machine:
+// docker-machine-<machine>-<timestamp>
+func (d *OscDriver) defaultImageName() string {
+	return fmt.Sprintf("docker-machine-%s-%d", d.GetMachineName(), time.Now().Unix())
+}
+
+// CreateImage creates an OMI from the machine, which can then be used with
+// --outscale-source-omi. The VM is stopped so that its file systems are
+// consistent, and started again if it was running. The OMI is tagged with the
+// machine name and the extra tags. An empty name gets a default one.
+func (d *OscDriver) CreateImage(name string) (string, error) {
+	if name == "" {
+		name = d.defaultImageName()
+	}
+
+	vmState, err := d.GetState()
+	if err != nil {
+		return "", err
+	}
+
+	wasRunning := vmState == state.Running
+	if wasRunning {
+		log.Infof("Stopping the VM '%v' to create the OMI", d.VmId)
+		if err := d.innerStop(false); err != nil {
+			return "", err
+		}
+	}
+
+	imageId, err := createImageFromVm(d, d.VmId, name)
+	if err == nil {
+		log.Infof("Waiting for the OMI '%v' to be available", imageId)
+		err = waitForImageAvailable(d, imageId)
+	}
+
+	// The VM is restarted even if the creation failed
+	if wasRunning {
+		if startErr := d.Start(); startErr != nil {
+			if err == nil {
+				return imageId, startErr
+			}
+			log.Warnf("Error while starting the VM again: %v", startErr)
+		}
+	}
+
+	if err != nil {
+		return imageId, err
+	}
+
+	if err := addMachineTag(d, imageId); err != nil {
+		return imageId, err
+	}
+
+	if err := addExtraTags(d, imageId, d.ExtraTagsAll); err != nil {
+		return imageId, err
+	}
+
+	return imageId, nil
+}
+
+func createImageFromVm(d *OscDriver, vmId string, name string) (string, error) {
+	log.Debugf("Creation of the OMI '%v' from the Vm '%v'", name, vmId)
+
+	// Get the client
+	oscApi, err := d.getClient()
+	if err != nil {
+		return "", err
+	}
+
+	request := osc.CreateImageRequest{
+		VmId:        osc.PtrString(vmId),
+		ImageName:   osc.PtrString(name),
+		Description: osc.PtrString(fmt.Sprintf("OMI created from docker-machine %s", d.GetMachineName())),
+	}
+
+	var httpRes *http.Response
+	var response osc.CreateImageResponse
+	err = retry.Do(
+		func() error {
+			var response_error error
+			response, httpRes, response_error = oscApi.client.ImageApi.CreateImage(oscApi.context).CreateImageRequest(request).Execute()
+			return wrapError(response_error, httpRes)
+		},
+		oscApi.retryOptions...,
+	)
+
+	if err != nil {
+		return "", fmt.Errorf("Error while submitting the Image creation request: %w", err)
+	}
+
+	if !response.HasImage() {
+		return "", errors.New("Error while creating the Image: there is no Image")
+	}
+
+	return response.Image.GetImageId(), nil
+}
+
+func waitForImageAvailable(d *OscDriver, imageId string) error {
+	return retry.Do(
+		func() error {
+			images, err := readImages(d, osc.FiltersImage{
+				ImageIds: &[]string{imageId},
+			})
+			if err != nil {
+				return err
+			}
+
+			if len(images) == 0 {
+				return fmt.Errorf("The Image '%v' has not been found", imageId)
+			}
+
+			switch images[0].GetState() {
+			case "available":
+				return nil
+			case "failed":
+				return retry.Unrecoverable(fmt.Errorf("The Image '%v' failed: %v", imageId, images[0].StateComment.GetStateMessage()))
+			default:
+				return fmt.Errorf("The Image is not (yet) available (state: '%v')", images[0].GetState())
+			}
+		},
+		retry.Attempts(uint(defaultImageTimeout/defaultImageDelay)),
+		retry.Delay(defaultImageDelay),
+		retry.DelayType(retry.FixedDelay),
+		retry.LastErrorOnly(true),
+		retry.OnRetry(func(n uint, err error) {
+			log.Debugf("%v, retrying...", err)
+		}),
+	)
+}
@@ -0,0 +1,90 @@
+package outscale
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	osc "github.com/outscale/osc-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultImageName(t *testing.T) {
+	d := NewDriver("builder", "")
+	assert.Regexp(t, `^docker-machine-builder-[0-9]+$`, d.defaultImageName())
+}
+
+// imageApiHandlers returns the handlers of a running VM from which an OMI is
+// created by createImage, recording the tags added
+func imageApiHandlers(vm *fakeVm, createImage fakeApiHandler, tags *[]string) map[string]fakeApiHandler {
+	var mutex sync.Mutex
+	return map[string]fakeApiHandler{
+		"ReadVms":     vm.read,
+		"StopVms":     vm.setState("stopped"),
+		"StartVms":    vm.setState("running"),
+		"CreateImage": createImage,
+		"ReadImages": fakeApiOk(osc.ReadImagesResponse{Images: &[]osc.Image{
+			{ImageId: osc.PtrString("ami-12345678"), State: osc.PtrString("available")},
+		}}),
+		"CreateTags": func(request map[string]interface{}) (int, interface{}) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			for _, tag := range request["Tags"].([]interface{}) {
+				tag := tag.(map[string]interface{})
+				*tags = append(*tags, tag["Key"].(string)+"="+tag["Value"].(string))
+			}
+			return http.StatusOK, struct{}{}
+		},
+	}
+}
+
+func newImageVm() *fakeVm {
+	return &fakeVm{vm: osc.Vm{
+		VmId:  osc.PtrString("i-12345678"),
+		State: osc.PtrString("running"),
+	}}
+}
+
+func TestCreateImage(t *testing.T) {
+	var name string
+	createImage := func(request map[string]interface{}) (int, interface{}) {
+		name = request["ImageName"].(string)
+		return http.StatusOK, osc.CreateImageResponse{Image: &osc.Image{ImageId: osc.PtrString("ami-12345678")}}
+	}
+
+	vm := newImageVm()
+	var tags []string
+	driver, api := newFakeApiDriver(t, "builder", imageApiHandlers(vm, createImage, &tags))
+	driver.VmId = "i-12345678"
+	driver.MachineId = "0123456789abcdef"
+	driver.ExtraTagsAll = []string{"team=ci"}
+
+	imageId, err := driver.CreateImage("")
+	assert.NoError(t, err)
+	assert.Equal(t, "ami-12345678", imageId)
+	assert.Regexp(t, `^docker-machine-builder-[0-9]+$`, name)
+
+	// The VM is stopped during the creation and started again
+	assert.Equal(t, "running", vm.vm.GetState())
+	assert.Subset(t, api.called(), []string{"StopVms", "CreateImage", "StartVms"})
+	assert.ElementsMatch(t, []string{"docker-machine-name=builder", "docker-machine-id=0123456789abcdef", "team=ci"}, tags)
+}
+
+func TestCreateImageRestartsOnFailure(t *testing.T) {
+	createImage := func(map[string]interface{}) (int, interface{}) {
+		return http.StatusBadRequest, fakeApiError("InvalidParameterValue")
+	}
+
+	vm := newImageVm()
+	var tags []string
+	driver, api := newFakeApiDriver(t, "builder", imageApiHandlers(vm, createImage, &tags))
+	driver.VmId = "i-12345678"
+
+	_, err := driver.CreateImage("base")
+	assert.ErrorContains(t, err, "Error while submitting the Image creation request")
+
+	// The VM has been started again and nothing has been tagged
+	assert.Equal(t, "running", vm.vm.GetState())
+	assert.Contains(t, api.called(), "StartVms")
+	assert.Empty(t, tags)
+}
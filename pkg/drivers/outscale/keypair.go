@@ -33,6 +33,15 @@ func (d *OscDriver) publicSSHKeyPath() string {
 	return d.GetSSHKeyPath() + ".pub"
 }
 
+// keypairName returns the name of the keypair of the machine:
+// docker-machine-<machine>-<machine id>, the keypairs can not be tagged
+func (d *OscDriver) keypairName() string {
+	if d.MachineId == "" {
+		return fmt.Sprintf("docker-machine-%s-%d", d.GetMachineName(), time.Now().Unix())
+	}
+	return fmt.Sprintf("docker-machine-%s-%s", d.GetMachineName(), d.MachineId)
+}
+
 // Create a Keypair for the VM
 func createKeyPair(d *OscDriver) error {
 
@@ -46,7 +55,7 @@ func createKeyPair(d *OscDriver) error {
 		return err
 	}
 
-	d.KeypairName = fmt.Sprintf("docker-machine-%s-%d", d.GetMachineName(), time.Now().Unix())
+	d.KeypairName = d.keypairName()
 
 	request := osc.CreateKeypairRequest{
 		KeypairName: d.KeypairName,
@@ -61,11 +70,11 @@ func createKeyPair(d *OscDriver) error {
 			response, httpRes, response_error = oscApi.client.KeypairApi.CreateKeypair(oscApi.context).CreateKeypairRequest(request).Execute()
 			return wrapError(response_error, httpRes)
 		},
-		defaultThrottlingRetryOption...,
+		oscApi.retryOptions...,
 	)
 
 	if err != nil {
-		return fmt.Errorf("Error while submitting the Keypair creation request: %s", getErrorInfo(err, httpRes))
+		return fmt.Errorf("Error while submitting the Keypair creation request: %w", err)
 	}
 
 	if !response.HasKeypair() {
@@ -98,12 +107,40 @@ func deleteKeyPair(d *OscDriver, keypairName string) error {
 			_, httpRes, response_error = oscApi.client.KeypairApi.DeleteKeypair(oscApi.context).DeleteKeypairRequest(request).Execute()
 			return wrapError(response_error, httpRes)
 		},
-		defaultThrottlingRetryOption...,
+		oscApi.retryOptions...,
 	)
 
 	if err != nil {
-		return fmt.Errorf("Error while submitting the Keypair deletetion request:  %s", getErrorInfo(err, httpRes))
+		return fmt.Errorf("Error while submitting the Keypair deletetion request: %w", err)
 	}
 
 	return nil
 }
+
+func readKeypairs(d *OscDriver, filters osc.FiltersKeypair) ([]osc.Keypair, error) {
+	oscApi, err := d.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	request := osc.ReadKeypairsRequest{
+		Filters: &filters,
+	}
+
+	var httpRes *http.Response
+	var response osc.ReadKeypairsResponse
+	err = retry.Do(
+		func() error {
+			var response_error error
+			response, httpRes, response_error = oscApi.client.KeypairApi.ReadKeypairs(oscApi.context).ReadKeypairsRequest(request).Execute()
+			return wrapError(response_error, httpRes)
+		},
+		oscApi.retryOptions...,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error while submitting the Keypair read request: %w", err)
+	}
+
+	return response.GetKeypairs(), nil
+}
@@ -0,0 +1,152 @@
+package outscale
+
+import (
+	"fmt"
+	"net/http"
+
+	retry "github.com/avast/retry-go"
+	"github.com/docker/machine/libmachine/log"
+	osc "github.com/outscale/osc-sdk-go/v2"
+)
+
+func readLoadBalancers(d *OscDriver, filters osc.FiltersLoadBalancer) ([]osc.LoadBalancer, error) {
+	oscApi, err := d.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	request := osc.ReadLoadBalancersRequest{
+		Filters: &filters,
+	}
+
+	var httpRes *http.Response
+	var response osc.ReadLoadBalancersResponse
+	err = retry.Do(
+		func() error {
+			var response_error error
+			response, httpRes, response_error = oscApi.client.LoadBalancerApi.ReadLoadBalancers(oscApi.context).ReadLoadBalancersRequest(request).Execute()
+			return wrapError(response_error, httpRes)
+		},
+		oscApi.retryOptions...,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error while submitting the Load Balancer read request: %w", err)
+	}
+
+	return response.GetLoadBalancers(), nil
+}
+
+// checkLoadBalancers checks that the load balancers exist and are in the Net
+// of the machine. It must be called once the Net of the subnet is known.
+func (d *OscDriver) checkLoadBalancers() error {
+	if len(d.LoadBalancerNames) == 0 {
+		return nil
+	}
+
+	loadBalancers, err := readLoadBalancers(d, osc.FiltersLoadBalancer{
+		LoadBalancerNames: &d.LoadBalancerNames,
+	})
+	if err != nil {
+		return err
+	}
+
+	if problems := d.loadBalancerProblems(loadBalancers); len(problems) > 0 {
+		return CompatibilityError{Problems: problems}
+	}
+
+	return nil
+}
+
+func (d *OscDriver) loadBalancerProblems(loadBalancers []osc.LoadBalancer) []string {
+	var problems []string
+
+	found := make(map[string]osc.LoadBalancer)
+	for _, loadBalancer := range loadBalancers {
+		found[loadBalancer.GetLoadBalancerName()] = loadBalancer
+	}
+
+	for _, name := range d.LoadBalancerNames {
+		loadBalancer, ok := found[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("The Load Balancer '%v' does not exist, check --%v.", name, flagLoadBalancerNames))
+			continue
+		}
+
+		lbNetId := loadBalancer.GetNetId()
+		switch {
+		case d.PublicCloud && lbNetId != "":
+			problems = append(problems, fmt.Sprintf("The Load Balancer '%v' belongs to the Net '%v' but the machine is created in the public cloud, set --%v to a subnet of this Net.", name, lbNetId, flagSubnetId))
+		case !d.PublicCloud && lbNetId != d.netId:
+			if lbNetId == "" {
+				problems = append(problems, fmt.Sprintf("The Load Balancer '%v' belongs to the public cloud but the Subnet '%v' is in the Net '%v', use a Load Balancer of this Net.", name, d.SubnetId, d.netId))
+			} else {
+				problems = append(problems, fmt.Sprintf("The Load Balancer '%v' belongs to the Net '%v' but the Subnet '%v' is in the Net '%v', use a Load Balancer of the same Net.", name, lbNetId, d.SubnetId, d.netId))
+			}
+		default:
+			log.Debugf("The Load Balancer '%v' exists.", name)
+		}
+	}
+
+	return problems
+}
+
+func registerVmInLoadBalancer(d *OscDriver, loadBalancerName string, vmId string) error {
+	log.Debugf("Registration of the Vm '%v' in the Load Balancer '%v'", vmId, loadBalancerName)
+
+	oscApi, err := d.getClient()
+	if err != nil {
+		return err
+	}
+
+	request := osc.RegisterVmsInLoadBalancerRequest{
+		BackendVmIds:     []string{vmId},
+		LoadBalancerName: loadBalancerName,
+	}
+
+	var httpRes *http.Response
+	err = retry.Do(
+		func() error {
+			var response_error error
+			_, httpRes, response_error = oscApi.client.LoadBalancerApi.RegisterVmsInLoadBalancer(oscApi.context).RegisterVmsInLoadBalancerRequest(request).Execute()
+			return wrapError(response_error, httpRes)
+		},
+		oscApi.retryOptions...,
+	)
+
+	if err != nil {
+		return fmt.Errorf("Error while submitting the Load Balancer registration request: %w", err)
+	}
+
+	return nil
+}
+
+func deregisterVmFromLoadBalancer(d *OscDriver, loadBalancerName string, vmId string) error {
+	log.Debugf("Deregistration of the Vm '%v' from the Load Balancer '%v'", vmId, loadBalancerName)
+
+	oscApi, err := d.getClient()
+	if err != nil {
+		return err
+	}
+
+	request := osc.DeregisterVmsInLoadBalancerRequest{
+		BackendVmIds:     []string{vmId},
+		LoadBalancerName: loadBalancerName,
+	}
+
+	var httpRes *http.Response
+	err = retry.Do(
+		func() error {
+			var response_error error
+			_, httpRes, response_error = oscApi.client.LoadBalancerApi.DeregisterVmsInLoadBalancer(oscApi.context).DeregisterVmsInLoadBalancerRequest(request).Execute()
+			return wrapError(response_error, httpRes)
+		},
+		oscApi.retryOptions...,
+	)
+
+	if err != nil {
+		return fmt.Errorf("Error while submitting the Load Balancer deregistration request: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package outscale
+
+import (
+	"testing"
+
+	osc "github.com/outscale/osc-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadBalancerProblems(t *testing.T) {
+	loadBalancers := []osc.LoadBalancer{
+		{LoadBalancerName: osc.PtrString("lbu-public")},
+		{LoadBalancerName: osc.PtrString("lbu-net-1"), NetId: osc.PtrString("vpc-1")},
+		{LoadBalancerName: osc.PtrString("lbu-net-2"), NetId: osc.PtrString("vpc-2")},
+	}
+
+	d := NewDriver("test", "")
+	d.PublicCloud = true
+	d.LoadBalancerNames = []string{"lbu-public", "lbu-net-1", "lbu-unknown"}
+	assert.Equal(t, []string{
+		"The Load Balancer 'lbu-net-1' belongs to the Net 'vpc-1' but the machine is created in the public cloud, set --outscale-subnet-id to a subnet of this Net.",
+		"The Load Balancer 'lbu-unknown' does not exist, check --outscale-load-balancer-name.",
+	}, d.loadBalancerProblems(loadBalancers))
+
+	d.PublicCloud = false
+	d.SubnetId = "subnet-1"
+	d.netId = "vpc-1"
+	d.LoadBalancerNames = []string{"lbu-public", "lbu-net-1", "lbu-net-2"}
+	assert.Equal(t, []string{
+		"The Load Balancer 'lbu-public' belongs to the public cloud but the Subnet 'subnet-1' is in the Net 'vpc-1', use a Load Balancer of this Net.",
+		"The Load Balancer 'lbu-net-2' belongs to the Net 'vpc-2' but the Subnet 'subnet-1' is in the Net 'vpc-1', use a Load Balancer of the same Net.",
+	}, d.loadBalancerProblems(loadBalancers))
+}
@@ -8,16 +8,14 @@ import (
 	osc "github.com/outscale/osc-sdk-go/v2"
 )
 
-func RetrieveNetFromSubnetId(d *OscDriver, subnetId string) (string, error) {
+func readSubnets(d *OscDriver, filters osc.FiltersSubnet) ([]osc.Subnet, error) {
 	oscApi, err := d.getClient()
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	request := osc.ReadSubnetsRequest{
-		Filters: &osc.FiltersSubnet{
-			SubnetIds: &[]string{subnetId},
-		},
+		Filters: &filters,
 	}
 
 	var httpRes *http.Response
@@ -28,18 +26,12 @@ func RetrieveNetFromSubnetId(d *OscDriver, subnetId string) (string, error) {
 			response, httpRes, response_error = oscApi.client.SubnetApi.ReadSubnets(oscApi.context).ReadSubnetsRequest(request).Execute()
 			return wrapError(response_error, httpRes)
 		},
-		defaultThrottlingRetryOption...,
+		oscApi.retryOptions...,
 	)
 
 	if err != nil {
-		return "", fmt.Errorf("Error while submitting the Subnet read request: %s", getErrorInfo(err, httpRes))
-	}
-
-	if ! response.HasSubnets() {
-		return "", fmt.Errorf("The subnet '%s' has not been found", subnetId)
+		return nil, fmt.Errorf("Error while submitting the Subnet read request: %w", err)
 	}
 
-	subnet := response.GetSubnets()[0];
-
-	return subnet.GetNetId(), nil
-}
\ No newline at end of file
+	return response.GetSubnets(), nil
+}
@@ -0,0 +1,205 @@
+package outscale
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	retry "github.com/avast/retry-go"
+	"github.com/docker/machine/libmachine/log"
+	osc "github.com/outscale/osc-sdk-go/v2"
+)
+
+// nicSpec describes an additional network interface of the VM, given as
+// subnet=<id>,sgs=<id>:<id>,private-ip=<ip>,delete-on-termination=<bool>
+type nicSpec struct {
+	subnetId           string
+	securityGroupIds   []string
+	privateIp          string
+	deleteOnVmDeletion bool
+}
+
+func parseNicSpec(spec string) (nicSpec, error) {
+	nic := nicSpec{
+		deleteOnVmDeletion: true,
+	}
+
+	for _, field := range strings.Split(spec, ",") {
+		splittedField := strings.SplitN(field, "=", 2)
+		if len(splittedField) != 2 {
+			return nic, fmt.Errorf("the field '%v' does not have the syntax 'key=value'", field)
+		}
+		key := strings.TrimSpace(splittedField[0])
+		value := strings.TrimSpace(splittedField[1])
+
+		switch key {
+		case "subnet":
+			nic.subnetId = value
+		case "sgs":
+			nic.securityGroupIds = strings.Split(value, ":")
+		case "private-ip":
+			if ip := net.ParseIP(value); ip == nil || ip.To4() == nil {
+				return nic, fmt.Errorf("'%v' is not a valid IPv4 address", value)
+			}
+			nic.privateIp = value
+		case "delete-on-termination":
+			deleteOnVmDeletion, err := strconv.ParseBool(value)
+			if err != nil {
+				return nic, fmt.Errorf("'%v' is not a valid boolean", value)
+			}
+			nic.deleteOnVmDeletion = deleteOnVmDeletion
+		default:
+			return nic, fmt.Errorf("the key '%v' is unknown (expected: 'subnet'|'sgs'|'private-ip'|'delete-on-termination')", key)
+		}
+	}
+
+	if nic.subnetId == "" {
+		return nic, fmt.Errorf("the subnet is required")
+	}
+
+	return nic, nil
+}
+
+func parseNicSpecs(specs []string) ([]nicSpec, error) {
+	var nics []nicSpec
+	for _, spec := range specs {
+		nic, err := parseNicSpec(spec)
+		if err != nil {
+			return nil, fmt.Errorf("the NIC '%v' is not valid: %v", spec, err)
+		}
+		nics = append(nics, nic)
+	}
+	return nics, nil
+}
+
+// extraNicPrivateIps returns the private IPs requested for the additional NICs
+func (d *OscDriver) extraNicPrivateIps() []string {
+	var privateIps []string
+	for _, nic := range d.extraNics {
+		if nic.privateIp != "" {
+			privateIps = append(privateIps, nic.privateIp)
+		}
+	}
+	return privateIps
+}
+
+// nicsForVmCreation returns the primary NIC, in the subnet and the security
+// groups of the machine, followed by the additional NICs
+func (d *OscDriver) nicsForVmCreation() []osc.NicForVmCreation {
+	primary := osc.NicForVmCreation{}
+	primary.SetDeviceNumber(0)
+	primary.SetSubnetId(d.SubnetId)
+	primary.SetSecurityGroupIds(d.vmSecurityGroupIds())
+	primary.SetDeleteOnVmDeletion(true)
+	if privateIps := d.primaryNicPrivateIps(); len(privateIps) > 0 {
+		primary.SetPrivateIps(privateIps)
+	}
+
+	nics := []osc.NicForVmCreation{primary}
+	for i, extraNic := range d.extraNics {
+		nic := osc.NicForVmCreation{}
+		nic.SetDeviceNumber(int32(i + 1))
+		nic.SetSubnetId(extraNic.subnetId)
+		nic.SetDeleteOnVmDeletion(extraNic.deleteOnVmDeletion)
+		nic.SetDescription(fmt.Sprintf("NIC %v of docker-machine %s", i+1, d.GetMachineName()))
+
+		if len(extraNic.securityGroupIds) > 0 {
+			nic.SetSecurityGroupIds(extraNic.securityGroupIds)
+		}
+
+		if extraNic.privateIp != "" {
+			nic.SetPrivateIps([]osc.PrivateIpLight{
+				{
+					IsPrimary: osc.PtrBool(true),
+					PrivateIp: osc.PtrString(extraNic.privateIp),
+				},
+			})
+		}
+
+		nics = append(nics, nic)
+	}
+
+	return nics
+}
+
+// storeExtraNics records the ids and the IPs of the additional NICs of the VM,
+// and the NICs kept when the machine is removed
+func (d *OscDriver) storeExtraNics(vm osc.Vm) {
+	d.ExtraNicIds = nil
+	d.ExtraNicIps = nil
+	d.KeptNicIds = nil
+
+	for deviceNumber := 1; deviceNumber <= len(d.extraNics); deviceNumber++ {
+		for _, nic := range vm.GetNics() {
+			if int(nic.LinkNic.GetDeviceNumber()) != deviceNumber {
+				continue
+			}
+
+			d.ExtraNicIds = append(d.ExtraNicIds, nic.GetNicId())
+			if !d.extraNics[deviceNumber-1].deleteOnVmDeletion {
+				d.KeptNicIds = append(d.KeptNicIds, nic.GetNicId())
+			}
+			for _, privateIp := range nic.GetPrivateIps() {
+				if privateIp.GetIsPrimary() {
+					d.ExtraNicIps = append(d.ExtraNicIps, privateIp.GetPrivateIp())
+				}
+			}
+		}
+	}
+}
+
+// tagExtraNics adds the machine tags to the additional NICs of the VM
+func tagExtraNics(d *OscDriver) error {
+	for _, nicId := range d.ExtraNicIds {
+		if err := addMachineTag(d, nicId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isKeptNic reports whether the NIC was created with
+// delete-on-termination=false, and must survive the machine
+func (d *OscDriver) isKeptNic(nicId string) bool {
+	for _, keptNicId := range d.KeptNicIds {
+		if keptNicId == nicId {
+			return true
+		}
+	}
+	return false
+}
+
+func deleteNic(d *OscDriver, nicId string) error {
+	log.Debugf("Deletion of the Nic '%v'", nicId)
+
+	// Get the client
+	oscApi, err := d.getClient()
+	if err != nil {
+		return err
+	}
+
+	request := osc.DeleteNicRequest{
+		NicId: nicId,
+	}
+
+	// The Nic stays in use until it is detached from the terminated VM
+	var httpRes *http.Response
+	err = retryWhileInUse(func() error {
+		return retry.Do(
+			func() error {
+				var response_error error
+				_, httpRes, response_error = oscApi.client.NicApi.DeleteNic(oscApi.context).DeleteNicRequest(request).Execute()
+				return wrapError(response_error, httpRes)
+			},
+			oscApi.retryOptions...,
+		)
+	})
+
+	if err != nil {
+		return fmt.Errorf("Error while submitting the Nic deletion request: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package outscale
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	osc "github.com/outscale/osc-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseNicSpec(t *testing.T) {
+	nic, err := parseNicSpec("subnet=subnet-1,sgs=sg-1:sg-2,private-ip=10.0.1.5,delete-on-termination=false")
+	assert.NoError(t, err)
+	assert.Equal(t, nicSpec{
+		subnetId:           "subnet-1",
+		securityGroupIds:   []string{"sg-1", "sg-2"},
+		privateIp:          "10.0.1.5",
+		deleteOnVmDeletion: false,
+	}, nic)
+
+	nic, err = parseNicSpec("subnet=subnet-1")
+	assert.NoError(t, err)
+	assert.True(t, nic.deleteOnVmDeletion)
+
+	errors := map[string]string{
+		"sgs=sg-1":                              "the subnet is required",
+		"subnet=subnet-1,private-ip=10.0.1":     "'10.0.1' is not a valid IPv4 address",
+		"subnet=subnet-1,private-ip=fd00::1":    "'fd00::1' is not a valid IPv4 address",
+		"subnet=subnet-1,delete-on-termination": "the field 'delete-on-termination' does not have the syntax 'key=value'",
+		"subnet=subnet-1,mtu=9000":              "the key 'mtu' is unknown (expected: 'subnet'|'sgs'|'private-ip'|'delete-on-termination')",
+	}
+	for spec, expected := range errors {
+		_, err := parseNicSpec(spec)
+		if assert.Errorf(t, err, "The NIC '%v' should be rejected", spec) {
+			assert.Equal(t, expected, err.Error())
+		}
+	}
+}
+
+func TestNicsForVmCreation(t *testing.T) {
+	driver := NewDriver("node1", "")
+	driver.SubnetId = "subnet-1"
+	driver.SecurityGroupIds = []string{"sg-1"}
+	driver.extraNics = []nicSpec{
+		{subnetId: "subnet-2", privateIp: "10.0.2.5", deleteOnVmDeletion: true},
+	}
+
+	nics := driver.nicsForVmCreation()
+	assert.Len(t, nics, 2)
+
+	assert.Equal(t, int32(0), nics[0].GetDeviceNumber())
+	assert.Equal(t, "subnet-1", nics[0].GetSubnetId())
+	assert.Equal(t, []string{"sg-1"}, nics[0].GetSecurityGroupIds())
+
+	assert.Equal(t, int32(1), nics[1].GetDeviceNumber())
+	assert.Equal(t, "subnet-2", nics[1].GetSubnetId())
+	assert.False(t, nics[1].HasSecurityGroupIds())
+	assert.Equal(t, "10.0.2.5", nics[1].GetPrivateIps()[0].GetPrivateIp())
+}
+
+func TestStoreExtraNics(t *testing.T) {
+	driver := NewDriver("node1", "")
+	driver.extraNics = []nicSpec{{subnetId: "subnet-2", deleteOnVmDeletion: true}, {subnetId: "subnet-3"}}
+
+	vm := osc.Vm{
+		Nics: &[]osc.NicLight{
+			{
+				NicId:      osc.PtrString("eni-primary"),
+				LinkNic:    &osc.LinkNicLight{DeviceNumber: osc.PtrInt32(0)},
+				PrivateIps: &[]osc.PrivateIpLightForVm{{IsPrimary: osc.PtrBool(true), PrivateIp: osc.PtrString("10.0.1.4")}},
+			},
+			{
+				NicId:      osc.PtrString("eni-storage"),
+				LinkNic:    &osc.LinkNicLight{DeviceNumber: osc.PtrInt32(1)},
+				PrivateIps: &[]osc.PrivateIpLightForVm{{IsPrimary: osc.PtrBool(true), PrivateIp: osc.PtrString("10.0.2.4")}},
+			},
+			{
+				NicId:      osc.PtrString("eni-kept"),
+				LinkNic:    &osc.LinkNicLight{DeviceNumber: osc.PtrInt32(2)},
+				PrivateIps: &[]osc.PrivateIpLightForVm{{IsPrimary: osc.PtrBool(true), PrivateIp: osc.PtrString("10.0.3.4")}},
+			},
+		},
+	}
+
+	driver.storeExtraNics(vm)
+	assert.Equal(t, []string{"eni-storage", "eni-kept"}, driver.ExtraNicIds)
+	assert.Equal(t, []string{"10.0.2.4", "10.0.3.4"}, driver.ExtraNicIps)
+	assert.Equal(t, []string{"eni-kept"}, driver.KeptNicIds)
+	assert.True(t, driver.isKeptNic("eni-kept"))
+	assert.False(t, driver.isKeptNic("eni-storage"))
+}
+
+func TestRemoveKeepsNics(t *testing.T) {
+	vm := &fakeVm{vm: osc.Vm{VmId: osc.PtrString("i-12345678"), State: osc.PtrString("terminated")}}
+	driver, api := newFakeApiDriver(t, "node1", map[string]fakeApiHandler{
+		"DeleteVms": fakeApiOk(struct{}{}),
+		"ReadVms":   vm.read,
+		"DeleteNic": fakeApiOk(struct{}{}),
+	})
+	driver.VmId = "i-12345678"
+	driver.ExtraNicIds = []string{"eni-storage", "eni-kept"}
+	driver.KeptNicIds = []string{"eni-kept"}
+
+	assert.NoError(t, driver.deleteResources())
+
+	var deletedNics int
+	for _, call := range api.called() {
+		if call == "DeleteNic" {
+			deletedNics++
+		}
+	}
+	assert.Equal(t, 1, deletedNics)
+}
+
+func TestTagExtraNics(t *testing.T) {
+	var mutex sync.Mutex
+	var taggedNics []interface{}
+	driver, _ := newFakeApiDriver(t, "node1", map[string]fakeApiHandler{
+		"CreateTags": func(request map[string]interface{}) (int, interface{}) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			taggedNics = append(taggedNics, request["ResourceIds"].([]interface{})...)
+			return http.StatusOK, struct{}{}
+		},
+	})
+	driver.MachineId = "0123456789abcdef"
+	driver.ExtraNicIds = []string{"eni-storage", "eni-kept"}
+
+	assert.NoError(t, tagExtraNics(driver))
+
+	// The name tag then the id tag
+	assert.Equal(t, []interface{}{"eni-storage", "eni-storage", "eni-kept", "eni-kept"}, taggedNics)
+}
+
+func TestNicSubnetProblems(t *testing.T) {
+	machineSubnet := osc.Subnet{SubnetId: osc.PtrString("subnet-1"), NetId: osc.PtrString("vpc-1"), SubregionName: osc.PtrString("eu-west-2a")}
+	subnets := []osc.Subnet{
+		{SubnetId: osc.PtrString("subnet-2"), NetId: osc.PtrString("vpc-1"), SubregionName: osc.PtrString("eu-west-2a")},
+		{SubnetId: osc.PtrString("subnet-3"), NetId: osc.PtrString("vpc-2"), SubregionName: osc.PtrString("eu-west-2a")},
+		{SubnetId: osc.PtrString("subnet-4"), NetId: osc.PtrString("vpc-1"), SubregionName: osc.PtrString("eu-west-2b")},
+	}
+	nics := []nicSpec{{subnetId: "subnet-2"}, {subnetId: "subnet-3"}, {subnetId: "subnet-4"}, {subnetId: "subnet-5"}}
+
+	assert.Equal(t, []string{
+		"The Subnet 'subnet-3' of an additional NIC is in the Net 'vpc-2' but the machine is in the Net 'vpc-1'.",
+		"The Subnet 'subnet-4' of an additional NIC is in the subregion 'eu-west-2b' but the machine is in the subregion 'eu-west-2a'.",
+		"The Subnet 'subnet-5' of an additional NIC does not exist, check --outscale-nic.",
+	}, nicSubnetProblems(nics, subnets, machineSubnet))
+}
+
+func TestExtraNicPrivateIpProblems(t *testing.T) {
+	var readNicsSubnets []interface{}
+	driver, _ := newFakeApiDriver(t, "node1", map[string]fakeApiHandler{
+		"ReadSubnets": fakeApiOk(osc.ReadSubnetsResponse{Subnets: &[]osc.Subnet{
+			{SubnetId: osc.PtrString("subnet-2"), NetId: osc.PtrString("vpc-1"), SubregionName: osc.PtrString("eu-west-2a"), IpRange: osc.PtrString("10.0.2.0/24")},
+		}}),
+		"ReadNics": func(request map[string]interface{}) (int, interface{}) {
+			filters := request["Filters"].(map[string]interface{})
+			readNicsSubnets = append(readNicsSubnets, filters["SubnetIds"].([]interface{})...)
+
+			var nics []osc.Nic
+			if filters["PrivateIpsPrivateIps"].([]interface{})[0] == "10.0.2.20" {
+				nics = append(nics, osc.Nic{
+					NicId:      osc.PtrString("eni-other"),
+					PrivateIps: &[]osc.PrivateIp{{PrivateIp: osc.PtrString("10.0.2.20")}},
+				})
+			}
+			return http.StatusOK, osc.ReadNicsResponse{Nics: &nics}
+		},
+	})
+	driver.extraNics = []nicSpec{
+		{subnetId: "subnet-2", privateIp: "10.0.2.10"},
+		{subnetId: "subnet-2", privateIp: "10.0.3.10"},
+		{subnetId: "subnet-2", privateIp: "10.0.2.20"},
+		{subnetId: "subnet-2"},
+	}
+	machineSubnet := osc.Subnet{SubnetId: osc.PtrString("subnet-1"), NetId: osc.PtrString("vpc-1"), SubregionName: osc.PtrString("eu-west-2a")}
+
+	problems, err := driver.extraNicProblems([]osc.Subnet{machineSubnet})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{
+		"The IP '10.0.3.10' is not in the IP range '10.0.2.0/24' of the Subnet 'subnet-2'.",
+		"The IP '10.0.2.20' is already used by the NIC 'eni-other' in the Subnet 'subnet-2'.",
+	}, problems)
+	assert.Equal(t, []interface{}{"subnet-2", "subnet-2", "subnet-2"}, readNicsSubnets)
+}
@@ -6,6 +6,10 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
 
 	retry "github.com/avast/retry-go"
 	"github.com/docker/machine/libmachine/drivers"
@@ -25,6 +29,7 @@ const (
 	defaultRootDiskType    = "gp2"
 	defaultRootDiskSize    = 15
 	defaultRootDiskIo1Iops = 1500
+	defaultTenancy         = "default"
 
 	flagAccessKey          = "outscale-access-key"
 	flagSecretKey          = "outscale-secret-key"
@@ -39,6 +44,29 @@ const (
 	flagRootDiskIo1Iops    = "outscale-root-disk-iops"
 	flagSubnetId           = "outscale-subnet-id"
 	flagK8sNodeNameTag     = "outscale-kubernetes-node-name-autotag"
+	flagRetryMaxAttempts   = "outscale-retry-max-attempts"
+	flagRetryMaxDelay      = "outscale-retry-max-delay"
+	flagRetryStatusCodes   = "outscale-retry-status-codes"
+	flagRateLimit          = "outscale-rate-limit"
+	flagRateLimitShared    = "outscale-rate-limit-shared"
+	flagPurgeOnRemove      = "outscale-purge-on-remove"
+	flagSubregion          = "outscale-subregion"
+	flagTenancy            = "outscale-tenancy"
+	flagPlacementStrategy  = "outscale-placement-strategy"
+	flagPlacementGroupTag  = "outscale-placement-group-tag"
+	flagPlacementSubnetIds = "outscale-placement-subnet-ids"
+	flagNics               = "outscale-nic"
+	flagPrivateIp          = "outscale-private-ip"
+	flagSecondaryIps       = "outscale-secondary-private-ips"
+	flagLoadBalancerNames  = "outscale-load-balancer-name"
+	flagRootDiskSnapshotId = "outscale-root-disk-snapshot-id"
+	flagDataDisks          = "outscale-data-disk"
+	flagSnapshotOnRemove   = "outscale-snapshot-on-remove"
+	flagVolumeTypes        = "outscale-volume-type"
+	flagCloneFrom          = "outscale-clone-from"
+	flagConfigFile         = "outscale-config-file"
+	flagSecurityGroupRules = "outscale-security-group-rule"
+	flagUserDataFile       = "outscale-user-data-file"
 )
 
 type OscDriver struct {
@@ -51,29 +79,65 @@ type OscDriver struct {
 	Sk     string
 	Region string
 
+	MachineId       string
 	VmId            string
 	KeypairName     string
 	SecurityGroupId string
 	PublicIpId      string
 	PublicCloud     bool
 
+	RetryMaxAttempts int
+	RetryMaxDelay    int
+	RetryStatusCodes []int
+	RateLimit        int
+	RateLimitShared  bool
+	PurgeOnRemove    bool
+	SnapshotOnRemove bool
+
+	ExtraNicIds []string
+	ExtraNicIps []string
+	KeptNicIds  []string
+
+	LoadBalancerNames []string
+
+	// Create options, stored to describe how the machine was built
+	InstanceType       string
+	SourceOmi          string
+	ExtraTagsAll       []string
+	ExtraTagsInstances []string
+	SecurityGroupIds   []string
+	RootDiskType       string
+	RootDiskSize       int32
+	RootDiskIo1Iops    int32
+	RootDiskSnapshotId string
+	DataDisks          []string
+	VolumeTypes        []string
+	SubnetId           string
+	TagK8sNodeName     bool
+	Subregion          string
+	Tenancy            string
+	PlacementStrategy  string
+	PlacementGroupTag  string
+	PlacementSubnetIds []string
+	ExtraNics          []string
+	PrivateIp          string
+	SecondaryIps       []string
+	SecurityGroupRules []string
+	UserDataFile       string
+
 	// Unstored
-	instanceType       string
-	sourceOmi          string
-	extraTagsAll       []string
-	extraTagsInstances []string
-	securityGroupIds   []string
-	rootDiskType       string
-	rootDiskSize       int32
-	rootDiskIo1Iops    int32
-	subnetId           string
 	netId              string
-	tagK8sNodeName     bool
+	dataDisks          []dataDiskSpec
+	volumeTypes        volumeTypeCatalog
+	extraNics          []nicSpec
+	securityGroupRules []securityGroupRuleSpec
+	userData           string
 }
 
 type OscApiData struct {
-	client  *osc.APIClient
-	context context.Context
+	client       *osc.APIClient
+	context      context.Context
+	retryOptions []retry.Option
 }
 
 // NewDriver creates and returns a new instance of the Outscale driver
@@ -83,6 +147,7 @@ func NewDriver(hostName, storePath string) *OscDriver {
 			MachineName: hostName,
 			StorePath:   storePath,
 		},
+		volumeTypes: defaultVolumeTypes,
 	}
 }
 
@@ -93,6 +158,15 @@ func (d *OscDriver) getClient() (*OscApiData, error) {
 		config.Debug = true
 		config.UserAgent = fmt.Sprintf("docker-machine-driver-outscale/%s", GetVersion())
 
+		if d.RateLimit > 0 {
+			config.HTTPClient = &http.Client{
+				Transport: &rateLimitedTransport{
+					limiter: newRateLimiter(d.RateLimit, d.rateLimitLockFile()),
+					next:    http.DefaultTransport,
+				},
+			}
+		}
+
 		client := osc.NewAPIClient(config)
 
 		ctx := context.WithValue(context.Background(), osc.ContextAWSv4, osc.AWSv4{
@@ -104,8 +178,9 @@ func (d *OscDriver) getClient() (*OscApiData, error) {
 		ctx = context.WithValue(ctx, osc.ContextServerVariables, map[string]string{"region": d.Region})
 
 		d.oscApi = &OscApiData{
-			client:  client,
-			context: ctx,
+			client:       client,
+			context:      ctx,
+			retryOptions: d.retryPolicy().options(),
 		}
 	}
 
@@ -113,6 +188,35 @@ func (d *OscDriver) getClient() (*OscApiData, error) {
 
 }
 
+// rateLimitLockFile returns the file shared by all the machines of the store
+// to coordinate their rate limit, or an empty string if it is not shared
+func (d *OscDriver) rateLimitLockFile() string {
+	if !d.RateLimitShared {
+		return ""
+	}
+	return filepath.Join(d.StorePath, rateLimitLockFile)
+}
+
+// retryPolicy returns the policy configured by the user, falling back to the
+// default values for machines created before these options existed
+func (d *OscDriver) retryPolicy() retryPolicy {
+	policy := defaultRetryPolicy()
+
+	if d.RetryMaxAttempts > 0 {
+		policy.maxAttempts = uint(d.RetryMaxAttempts)
+	}
+
+	if d.RetryMaxDelay > 0 {
+		policy.maxDelay = time.Duration(d.RetryMaxDelay) * time.Second
+	}
+
+	if len(d.RetryStatusCodes) > 0 {
+		policy.statusCodes = d.RetryStatusCodes
+	}
+
+	return policy
+}
+
 // Create a host using the driver's config
 func (d *OscDriver) Create() error {
 	log.Debug("Creating a Vm")
@@ -130,14 +234,12 @@ func (d *OscDriver) Create() error {
 	}
 
 	// Create a SG
-	if d.securityGroupIds == nil {
+	if len(d.SecurityGroupIds) == 0 {
 		// Create default SG
 		if err := createDefaultSecurityGroup(d); err != nil {
 			cleanUp(d)
 			return err
 		}
-
-		d.securityGroupIds = []string{d.SecurityGroupId}
 	}
 
 	// (TODO) Assign an Public IP
@@ -149,31 +251,38 @@ func (d *OscDriver) Create() error {
 	}
 
 	// Create an Instance
-	deviceName := "/dev/sda1"
-	rootDisk := osc.BlockDeviceMappingVmCreation{
-		Bsu: &osc.BsuToCreate{
-			VolumeType: &d.rootDiskType,
-			VolumeSize: &d.rootDiskSize,
-		},
-		DeviceName: &deviceName,
+	securityGroupIds := d.vmSecurityGroupIds()
+	createVmRequest := osc.CreateVmsRequest{
+		ImageId:          d.SourceOmi,
+		KeypairName:      &d.KeypairName,
+		VmType:           &d.InstanceType,
+		SecurityGroupIds: &securityGroupIds,
 	}
+	createVmRequest.SetBlockDeviceMappings(d.blockDeviceMappings())
 
-	if d.rootDiskType == "io1" {
-		rootDisk.Bsu.SetIops(d.rootDiskIo1Iops)
+	if d.userData != "" {
+		createVmRequest.SetUserData(d.userData)
 	}
 
-	createVmRequest := osc.CreateVmsRequest{
-		ImageId:          d.sourceOmi,
-		KeypairName:      &d.KeypairName,
-		VmType:           &d.instanceType,
-		SecurityGroupIds: &d.securityGroupIds,
-		BlockDeviceMappings: &[]osc.BlockDeviceMappingVmCreation{
-			rootDisk,
-		},
+	if !d.PublicCloud {
+		createVmRequest.SetSubnetId(d.SubnetId)
 	}
 
-	if !d.PublicCloud {
-		createVmRequest.SetSubnetId(d.subnetId)
+	if d.PrivateIp != "" {
+		createVmRequest.SetPrivateIps([]string{d.PrivateIp})
+	}
+
+	// The subnet, the SG and the IPs are set on the primary NIC when there are
+	// several NICs or several IPs
+	if len(d.extraNics) > 0 || len(d.SecondaryIps) > 0 {
+		createVmRequest.PrivateIps = nil
+		createVmRequest.SubnetId = nil
+		createVmRequest.SecurityGroupIds = nil
+		createVmRequest.SetNics(d.nicsForVmCreation())
+	}
+
+	if placement := d.placement(); placement != nil {
+		createVmRequest.SetPlacement(*placement)
 	}
 
 	var createVmResponse osc.CreateVmsResponse
@@ -184,12 +293,12 @@ func (d *OscDriver) Create() error {
 			createVmResponse, httpRes, response_error = oscApi.client.VmApi.CreateVms(oscApi.context).CreateVmsRequest(createVmRequest).Execute()
 			return wrapError(response_error, httpRes)
 		},
-		defaultThrottlingRetryOption...,
+		oscApi.retryOptions...,
 	)
 
 	if err != nil {
 		cleanUp(d)
-		return fmt.Errorf("Error while submitting the Vm creation request: %s", getErrorInfo(err, httpRes))
+		return fmt.Errorf("Error while submitting the Vm creation request: %w", err)
 	}
 
 	if !createVmResponse.HasVms() || len(createVmResponse.GetVms()) != 1 {
@@ -223,11 +332,11 @@ func (d *OscDriver) Create() error {
 			response, httpRes, response_error = oscApi.client.VmApi.ReadVms(oscApi.context).ReadVmsRequest(readVmRequest).Execute()
 			return wrapError(response_error, httpRes)
 		},
-		defaultThrottlingRetryOption...,
+		oscApi.retryOptions...,
 	)
 	if err != nil {
 		cleanUp(d)
-		return fmt.Errorf("Error while submitting the Vm read request: %s", getErrorInfo(err, httpRes))
+		return fmt.Errorf("Error while submitting the Vm read request: %w", err)
 	}
 
 	if !response.HasVms() {
@@ -235,6 +344,21 @@ func (d *OscDriver) Create() error {
 		return errors.New("Error while reading the VM: there is no VM")
 	}
 
+	d.storeExtraNics(response.GetVms()[0])
+	d.logRootVolumePerformance(response.GetVms()[0])
+
+	// The volumes and the NICs are purged with the machine if they are left
+	// behind
+	if err := tagVolumes(d, response.GetVms()[0]); err != nil {
+		cleanUp(d)
+		return err
+	}
+
+	if err := tagExtraNics(d); err != nil {
+		cleanUp(d)
+		return err
+	}
+
 	if d.PublicCloud {
 		// Link the Public Ip
 		if err := linkPublicIp(d); err != nil {
@@ -245,13 +369,35 @@ func (d *OscDriver) Create() error {
 		d.IPAddress = response.GetVms()[0].GetPrivateIp()
 	}
 
+	// Register the VM as a backend of the load balancers
+	for _, loadBalancerName := range d.LoadBalancerNames {
+		if err := registerVmInLoadBalancer(d, loadBalancerName, d.VmId); err != nil {
+			cleanUp(d)
+			return err
+		}
+	}
+
 	// Add the tag of the Vm name
 	if err := addTag(d, d.VmId, "name", d.GetMachineName()); err != nil {
 		cleanUp(d)
 		return err
 	}
 
-	if d.tagK8sNodeName {
+	if err := addMachineTag(d, d.VmId); err != nil {
+		cleanUp(d)
+		return err
+	}
+
+	// The VM must be counted in its placement group by the next machines
+	if d.PlacementStrategy == placementStrategySpread {
+		key, value := d.placementGroupTagKeyValue()
+		if err := addTag(d, d.VmId, key, value); err != nil {
+			cleanUp(d)
+			return err
+		}
+	}
+
+	if d.TagK8sNodeName {
 		// Add the tag of the Vm name
 		if err := addTag(d, d.VmId, "OscK8sNodeName", d.GetMachineName()); err != nil {
 			cleanUp(d)
@@ -260,13 +406,13 @@ func (d *OscDriver) Create() error {
 	}
 
 	// Add extra tags to the Instances
-	if err := addExtraTags(d, d.VmId, d.extraTagsAll); err != nil {
+	if err := addExtraTags(d, d.VmId, d.ExtraTagsAll); err != nil {
 		cleanUp(d)
 		return err
 	}
 
 	// Add extra tags only for the Instances
-	if err := addExtraTags(d, d.VmId, d.extraTagsInstances); err != nil {
+	if err := addExtraTags(d, d.VmId, d.ExtraTagsInstances); err != nil {
 		cleanUp(d)
 		return err
 	}
@@ -282,7 +428,7 @@ func (d *OscDriver) DriverName() string {
 // GetCreateFlags returns the mcnflag.Flag slice representing the flags
 // that can be set, their descriptions and defaults.
 func (d *OscDriver) GetCreateFlags() []mcnflag.Flag {
-	return []mcnflag.Flag{
+	flags := []mcnflag.Flag{
 		mcnflag.StringFlag{
 			EnvVar: "OUTSCALE_ACCESS_KEY",
 			Name:   flagAccessKey,
@@ -301,6 +447,18 @@ func (d *OscDriver) GetCreateFlags() []mcnflag.Flag {
 			Usage:  "Outscale Region (e.g. eu-west-2)",
 			Value:  "",
 		},
+		mcnflag.StringFlag{
+			EnvVar: "",
+			Name:   flagCloneFrom,
+			Usage:  "Machine of the store whose create options are used, unless set by another flag",
+			Value:  "",
+		},
+		mcnflag.StringFlag{
+			EnvVar: "OUTSCALE_CONFIG_FILE",
+			Name:   flagConfigFile,
+			Usage:  "YAML or JSON file describing the machine, whose options are used unless set by another flag",
+			Value:  "",
+		},
 		mcnflag.StringFlag{
 			EnvVar: "OUTSCALE_INSTANCE_TYPE",
 			Name:   flagInstanceType,
@@ -331,24 +489,54 @@ func (d *OscDriver) GetCreateFlags() []mcnflag.Flag {
 			Usage:  "Add machine into theses security groups",
 			Value:  nil,
 		},
+		mcnflag.StringSliceFlag{
+			EnvVar: "",
+			Name:   flagSecurityGroupRules,
+			Usage:  "Additional inbound rule <protocol=tcp|udp,ports=port|from-to,ip-range=cidr> of the security group created by the driver. Can be set multiple times",
+			Value:  nil,
+		},
+		mcnflag.StringFlag{
+			EnvVar: "",
+			Name:   flagUserDataFile,
+			Usage:  "File whose content is given to the VM as user data (e.g. a cloud-init configuration)",
+			Value:  "",
+		},
 		mcnflag.StringFlag{
 			EnvVar: "",
 			Name:   flagRootDiskType,
-			Usage:  "Type of volume for the root disk ('standard', 'io1' or 'gp2')",
+			Usage:  "Type of volume for the root disk ('standard', 'io1', 'gp2' or a type of --outscale-volume-type)",
 			Value:  defaultRootDiskType,
 		},
 		mcnflag.IntFlag{
 			EnvVar: "",
 			Name:   flagRootDiskSize,
-			Usage:  "Size of the root disk in GB (> 0)",
+			Usage:  "Size of the root disk in GB (between 1 and 14901, at least 4 for io1)",
 			Value:  defaultRootDiskSize,
 		},
 		mcnflag.IntFlag{
 			EnvVar: "",
 			Name:   flagRootDiskIo1Iops,
-			Usage:  "Iops for the io1 root disk type (ignore if it is not io1). Value between 1 and 13000.",
+			Usage:  fmt.Sprintf("Iops of the root disk when its type provisions IOPS (ignored otherwise), within the limits of the type (%v; or those of --%v)", defaultVolumeTypes.iopsLimits(), flagVolumeTypes),
 			Value:  defaultRootDiskIo1Iops,
 		},
+		mcnflag.StringFlag{
+			EnvVar: "",
+			Name:   flagRootDiskSnapshotId,
+			Usage:  "Snapshot from which the root disk is created",
+			Value:  "",
+		},
+		mcnflag.StringSliceFlag{
+			EnvVar: "",
+			Name:   flagVolumeTypes,
+			Usage:  "Additional volume type <name=type,size=min-max,iops=min-max,iops-per-gib=n,baseline-iops-per-gib=n,baseline-iops=min-max>, or new limits of a known one. Can be set multiple times",
+			Value:  nil,
+		},
+		mcnflag.StringSliceFlag{
+			EnvVar: "",
+			Name:   flagDataDisks,
+			Usage:  "Additional volume <size=GiB,type=type,iops=iops,snapshot=id> deleted with the VM. Can be set up to 25 times",
+			Value:  nil,
+		},
 		mcnflag.StringFlag{
 			EnvVar: "",
 			Name:   flagSubnetId,
@@ -360,7 +548,114 @@ func (d *OscDriver) GetCreateFlags() []mcnflag.Flag {
 			Name:   flagK8sNodeNameTag,
 			Usage:  "Automatically add kubernetes tag 'OscK8sNodeName' to the instance (Useful for the CCM)",
 		},
+		mcnflag.IntFlag{
+			EnvVar: "OUTSCALE_RETRY_MAX_ATTEMPTS",
+			Name:   flagRetryMaxAttempts,
+			Usage:  "Maximum number of attempts for an API call failing with a retryable error (> 0)",
+			Value:  defaultThrottlingMaxAttempts,
+		},
+		mcnflag.IntFlag{
+			EnvVar: "OUTSCALE_RETRY_MAX_DELAY",
+			Name:   flagRetryMaxDelay,
+			Usage:  "Maximum delay in seconds between two attempts of an API call (> 0)",
+			Value:  int(defaultThrottlingDelay.Seconds()),
+		},
+		mcnflag.StringSliceFlag{
+			EnvVar: "OUTSCALE_RETRY_STATUS_CODES",
+			Name:   flagRetryStatusCodes,
+			Usage:  "HTTP status codes of the API responses to retry (default: 429 and 503)",
+			Value:  nil,
+		},
+		mcnflag.IntFlag{
+			EnvVar: "OUTSCALE_RATE_LIMIT",
+			Name:   flagRateLimit,
+			Usage:  "Maximum number of API requests per second sent by the driver (0 to disable)",
+			Value:  0,
+		},
+		mcnflag.BoolFlag{
+			EnvVar: "OUTSCALE_RATE_LIMIT_SHARED",
+			Name:   flagRateLimitShared,
+			Usage:  "Share the rate limit between all the driver processes using the same machine store",
+		},
+		mcnflag.StringFlag{
+			EnvVar: "OUTSCALE_SUBREGION",
+			Name:   flagSubregion,
+			Usage:  "Subregion where the VM is created (e.g. eu-west-2a). With a subnet, it must be the subregion of the subnet",
+			Value:  "",
+		},
+		mcnflag.StringFlag{
+			EnvVar: "OUTSCALE_TENANCY",
+			Name:   flagTenancy,
+			Usage:  "Tenancy of the VM ('default' or 'dedicated')",
+			Value:  defaultTenancy,
+		},
+		mcnflag.StringFlag{
+			EnvVar: "OUTSCALE_PLACEMENT_STRATEGY",
+			Name:   flagPlacementStrategy,
+			Usage:  "Placement strategy of the VM ('spread' to choose the subregion with the fewest VMs of the placement group)",
+			Value:  placementStrategyNone,
+		},
+		mcnflag.StringFlag{
+			EnvVar: "OUTSCALE_PLACEMENT_GROUP_TAG",
+			Name:   flagPlacementGroupTag,
+			Usage:  "Tag <key=value> identifying the VMs of the placement group (required by the 'spread' strategy)",
+			Value:  "",
+		},
+		mcnflag.StringSliceFlag{
+			EnvVar: "",
+			Name:   flagPlacementSubnetIds,
+			Usage:  "Subnets, one per subregion, among which the 'spread' strategy chooses. Can be set multiple times",
+			Value:  nil,
+		},
+		mcnflag.StringSliceFlag{
+			EnvVar: "",
+			Name:   flagNics,
+			Usage:  "Additional NIC <subnet=id,sgs=id:id,private-ip=ip,delete-on-termination=bool> (requires --outscale-subnet-id). Can be set multiple times",
+			Value:  nil,
+		},
+		mcnflag.StringFlag{
+			EnvVar: "",
+			Name:   flagPrivateIp,
+			Usage:  "Private IP of the VM in its subnet (requires --outscale-subnet-id)",
+			Value:  "",
+		},
+		mcnflag.StringSliceFlag{
+			EnvVar: "",
+			Name:   flagSecondaryIps,
+			Usage:  "Secondary private IP of the VM in its subnet (requires --outscale-private-ip). Can be set multiple times",
+			Value:  nil,
+		},
+		mcnflag.StringSliceFlag{
+			EnvVar: "",
+			Name:   flagLoadBalancerNames,
+			Usage:  "Load Balancer (LBU) in which the VM is registered, in the Net of the machine. Can be set multiple times",
+			Value:  nil,
+		},
+		mcnflag.BoolFlag{
+			EnvVar: "",
+			Name:   flagSnapshotOnRemove,
+			Usage:  "On removal, snapshot every volume of the VM before deleting it",
+		},
+		mcnflag.BoolFlag{
+			EnvVar: purgeOnRemoveEnvVar,
+			Name:   flagPurgeOnRemove,
+			Usage:  "On removal, also delete all the resources tagged with the machine id, even if they are not stored in the machine config",
+		},
 	}
+
+	// A bool option of the machine to clone or of the config file can only be
+	// turned off by another flag
+	for _, flag := range flags {
+		if _, ok := flag.(mcnflag.BoolFlag); ok {
+			flags = append(flags, mcnflag.BoolFlag{
+				EnvVar: "",
+				Name:   negatedFlag(flag.String()),
+				Usage:  fmt.Sprintf("Turn off --%v set by --%v or --%v", flag.String(), flagCloneFrom, flagConfigFile),
+			})
+		}
+	}
+
+	return flags
 }
 
 // GetIP returns an IP or hostname that this host is available at
@@ -415,11 +710,11 @@ func (d *OscDriver) GetState() (state.State, error) {
 			readVmResponse, httpRes, response_error = oscApi.client.VmApi.ReadVms(oscApi.context).ReadVmsRequest(readVmRequest).Execute()
 			return wrapError(response_error, httpRes)
 		},
-		defaultThrottlingRetryOption...,
+		oscApi.retryOptions...,
 	)
 
 	if err != nil {
-		return state.None, fmt.Errorf("Error while submitting the Vm creation request: %s", getErrorInfo(err, httpRes))
+		return state.None, fmt.Errorf("Error while submitting the Vm creation request: %w", err)
 	}
 
 	if !readVmResponse.HasVms() {
@@ -456,41 +751,36 @@ func (d *OscDriver) PreCreateCheck() error {
 			_, httpRes, response_error = oscApi.client.AccountApi.ReadAccounts(oscApi.context).ReadAccountsRequest(request).Execute()
 			return wrapError(response_error, httpRes)
 		},
-		defaultThrottlingRetryOption...,
+		oscApi.retryOptions...,
 	)
 
 	if err != nil {
-		return fmt.Errorf("Error while submitting the ReadAccount request: %s", getErrorInfo(err, httpRes))
+		return fmt.Errorf("Error while submitting the ReadAccount request: %w", err)
 	}
 
-	// Check the SG
-	for _, sgId := range d.securityGroupIds {
-		sgExist, sgError := isSecurityGroupExist(d, sgId)
-		if sgError != nil {
-			return sgError
-		}
-
-		if !sgExist {
-			return fmt.Errorf("The Security Group '%v' does not exist.", sgId)
-		}
-
-		log.Debugf("The Security Group '%v' exists.", sgId)
+	// Check the VM type before creating any resource
+	if err := validateVmType(d, d.InstanceType); err != nil {
+		return err
 	}
 
-	// Check the SubnetId
-	if !d.PublicCloud {
-		netId, err := RetrieveNetFromSubnetId(d, d.subnetId)
-		if err != nil {
-			return err
-		}
+	// Choose the subregion before checking the placement
+	if err := d.applyPlacementStrategy(); err != nil {
+		return err
+	}
 
-		if len(netId) == 0 {
-			return fmt.Errorf("The Subnet Id '%v' does not exist.", d.subnetId)
-		}
+	// Check that the account can afford the machine
+	if err := d.checkQuotas(); err != nil {
+		return err
+	}
 
-		d.netId = netId
+	// Check the subnet, the SG and the OMI
+	if err := d.checkCompatibility(); err != nil {
+		return err
+	}
 
-		log.Debugf("The Subnet Id '%v' exists in NetId '%v'", d.subnetId, d.netId)
+	// Check the load balancers once the Net is known
+	if err := d.checkLoadBalancers(); err != nil {
+		return err
 	}
 
 	return nil
@@ -499,52 +789,101 @@ func (d *OscDriver) PreCreateCheck() error {
 
 // Remove a host
 func (d *OscDriver) Remove() error {
-	oscApi, err := d.getClient()
-	if err != nil {
-		return err
+	// Nothing is deleted when the state of the machine can not be preserved
+	if d.snapshotOnRemove() && d.VmId != "" {
+		snapshotIds, err := d.Snapshot()
+		if err := ignoreNotFound(err, "VM", d.VmId); err != nil {
+			return fmt.Errorf("Error while snapshotting the volumes before the removal, the machine has not been removed: %w", err)
+		}
+		if len(snapshotIds) > 0 {
+			log.Infof("The volumes of the machine have been saved in the snapshots %v", snapshotIds)
+		}
 	}
 
+	return d.deleteResources()
+}
+
+// deleteResources deletes the VM and all the resources created with it
+func (d *OscDriver) deleteResources() error {
+	var errs MultiError
+
+	// The VM is removed from the load balancers before being deleted
 	if d.VmId != "" {
-		request := osc.DeleteVmsRequest{
-			VmIds: []string{
-				d.VmId,
-			},
+		for _, loadBalancerName := range d.LoadBalancerNames {
+			if err := ignoreNotFound(deregisterVmFromLoadBalancer(d, loadBalancerName, d.VmId), "load balancer", loadBalancerName); err != nil {
+				errs = append(errs, err)
+			}
 		}
+	}
 
-		var httpRes *http.Response
-		err = retry.Do(
-			func() error {
-				var response_error error
-				_, httpRes, response_error = oscApi.client.VmApi.DeleteVms(oscApi.context).DeleteVmsRequest(request).Execute()
-				return wrapError(response_error, httpRes)
-			},
-			defaultThrottlingRetryOption...,
-		)
+	if err := ignoreNotFound(deleteVm(d, d.VmId), "VM", d.VmId); err != nil {
+		errs = append(errs, err)
+	}
 
-		if err != nil {
-			return fmt.Errorf("Error while submitting the DeleteVm request: %s", getErrorInfo(err, httpRes))
+	for _, nicId := range d.ExtraNicIds {
+		if d.isKeptNic(nicId) {
+			log.Infof("Keeping the NIC '%v' created with delete-on-termination=false", nicId)
+			continue
+		}
+		if err := ignoreNotFound(deleteNic(d, nicId), "NIC", nicId); err != nil {
+			errs = append(errs, err)
 		}
+	}
 
-		if err := d.waitForState(d.VmId, "terminated"); err != nil {
-			return err
+	// The VMs left behind are deleted before the security group they use
+	if d.purgeOnRemove() {
+		if err := d.purge(); err != nil {
+			errs = append(errs, err)
 		}
-	} else {
-		log.Warn("Skipping deletion of the VM because none was stored.")
 	}
 
-	if err := deletePublicIp(d, d.PublicIpId); err != nil {
-		return err
+	if err := ignoreNotFound(deletePublicIp(d, d.PublicIpId), "public IP", d.PublicIpId); err != nil {
+		errs = append(errs, err)
 	}
 
-	if err := deleteSecurityGroup(d, d.SecurityGroupId); err != nil {
-		return err
+	if err := ignoreNotFound(deleteSecurityGroup(d, d.SecurityGroupId), "security group", d.SecurityGroupId); err != nil {
+		errs = append(errs, err)
 	}
 
-	if err := deleteKeyPair(d, d.KeypairName); err != nil {
+	if err := ignoreNotFound(deleteKeyPair(d, d.KeypairName), "keypair", d.KeypairName); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errs.ErrorOrNil()
+}
+
+func deleteVm(d *OscDriver, vmId string) error {
+	oscApi, err := d.getClient()
+	if err != nil {
 		return err
 	}
 
-	return nil
+	if vmId == "" {
+		log.Warn("Skipping deletion of the VM because none was stored.")
+		return nil
+	}
+
+	request := osc.DeleteVmsRequest{
+		VmIds: []string{
+			vmId,
+		},
+	}
+
+	var httpRes *http.Response
+	err = retry.Do(
+		func() error {
+			var response_error error
+			_, httpRes, response_error = oscApi.client.VmApi.DeleteVms(oscApi.context).DeleteVmsRequest(request).Execute()
+			return wrapError(response_error, httpRes)
+		},
+		oscApi.retryOptions...,
+	)
+
+	if err != nil {
+		return fmt.Errorf("Error while submitting the DeleteVm request: %w", err)
+	}
+
+	return d.waitForState(vmId, "terminated")
 }
 
 // Restart a host. This may just call Stop(); Start() if the provider does not
@@ -568,11 +907,11 @@ func (d *OscDriver) Restart() error {
 			_, httpRes, response_error = oscApi.client.VmApi.RebootVms(oscApi.context).RebootVmsRequest(request).Execute()
 			return wrapError(response_error, httpRes)
 		},
-		defaultThrottlingRetryOption...,
+		oscApi.retryOptions...,
 	)
 
 	if err != nil {
-		return fmt.Errorf("Error while submitting the RebootVm request: %s", getErrorInfo(err, httpRes))
+		return fmt.Errorf("Error while submitting the RebootVm request: %w", err)
 	}
 
 	if err := d.waitForState(d.VmId, "running"); err != nil {
@@ -585,6 +924,46 @@ func (d *OscDriver) Restart() error {
 // SetConfigFromFlags configures the driver with the object that was returned
 // by RegisterCreateFlags
 func (d *OscDriver) SetConfigFromFlags(flags drivers.DriverOptions) error {
+	// The options of the machine to clone, then the ones of the config file,
+	// apply unless a flag is set
+	options := make(map[string]interface{})
+	if source := flags.String(flagCloneFrom); source != "" {
+		cloned, err := cloneOptions(d.StorePath, source)
+		if err != nil {
+			return err
+		}
+		for key, value := range cloned {
+			options[key] = value
+		}
+	}
+
+	if configFile := flags.String(flagConfigFile); configFile != "" {
+		// The disks of the file are checked against the volume types of the
+		// flags or of the machine to clone
+		volumeTypes := newLayeredDriverOptions(flags, d.GetCreateFlags(), options).StringSlice(flagVolumeTypes)
+		described, err := configFileOptions(configFile, volumeTypes)
+		if err != nil {
+			return err
+		}
+		for key, value := range described {
+			options[key] = value
+		}
+	}
+
+	if len(options) > 0 {
+		flags = newLayeredDriverOptions(flags, d.GetCreateFlags(), options)
+	}
+
+	// The id tells the resources of the machine apart from the ones of other
+	// machines with the same name
+	if d.MachineId == "" {
+		machineId, err := newMachineId()
+		if err != nil {
+			return err
+		}
+		d.MachineId = machineId
+	}
+
 	if d.Ak = flags.String(flagAccessKey); d.Ak == "" {
 		if d.Ak = os.Getenv("OSC_ACCESS_KEY"); d.Ak == "" {
 			return errors.New("Outscale Access Key is required")
@@ -603,39 +982,169 @@ func (d *OscDriver) SetConfigFromFlags(flags drivers.DriverOptions) error {
 		}
 	}
 
-	d.instanceType = flags.String(flagInstanceType)
-	d.sourceOmi = flags.String(flagSourceOmi)
+	d.InstanceType = flags.String(flagInstanceType)
+	d.SourceOmi = flags.String(flagSourceOmi)
+
+	// Volume types
+	d.VolumeTypes = flags.StringSlice(flagVolumeTypes)
+	volumeTypes, err := volumeTypesWith(d.VolumeTypes)
+	if err != nil {
+		return err
+	}
+	d.volumeTypes = volumeTypes
 
 	// Root disk
-	d.rootDiskType = flags.String(flagRootDiskType)
-	if !validateDiskType(d.rootDiskType) {
-		return fmt.Errorf("the disk type is not accepted (got: %s, expected: 'standard'|'io1'|'gp2')", d.rootDiskType)
+	d.RootDiskType = flags.String(flagRootDiskType)
+	if !validateDiskType(d.volumeTypes, d.RootDiskType) {
+		return fmt.Errorf("the disk type is not accepted (got: %s, expected: %s)", d.RootDiskType, d.volumeTypes.supported())
+	}
+	if d.RootDiskSize = int32(flags.Int(flagRootDiskSize)); d.RootDiskSize <= 0 {
+		return fmt.Errorf("the disk size (%v) is not accepted, it must be > 0", d.RootDiskSize)
+	}
+
+	// The iops are checked against the limits of the root disk type, and
+	// ignored when it does not provision them
+	d.RootDiskIo1Iops = int32(flags.Int(flagRootDiskIo1Iops))
+	rootDiskIops := int32(0)
+	if d.volumeTypes[d.RootDiskType].maxIops > 0 {
+		rootDiskIops = d.RootDiskIo1Iops
+	} else if d.RootDiskIo1Iops <= 0 {
+		return fmt.Errorf("the disk iops (%v) is not accepted, it must be > 0", d.RootDiskIo1Iops)
 	}
-	if d.rootDiskSize = int32(flags.Int(flagRootDiskSize)); d.rootDiskSize <= 0 {
-		return fmt.Errorf("the disk size (%v) is not accepted, it must be > 0", d.rootDiskSize)
+	if err := d.volumeTypes.validate(d.RootDiskType, d.RootDiskSize, rootDiskIops); err != nil {
+		return fmt.Errorf("the root disk is not accepted: %v", err)
 	}
+	d.RootDiskSnapshotId = flags.String(flagRootDiskSnapshotId)
 
-	if d.rootDiskIo1Iops = int32(flags.Int(flagRootDiskIo1Iops)); d.rootDiskIo1Iops <= 0 {
-		return fmt.Errorf("the disk iops (%v) is not accepted, it must between 1 and 13000", d.rootDiskIo1Iops)
+	// Data disks
+	d.DataDisks = flags.StringSlice(flagDataDisks)
+	dataDisks, err := parseDataDiskSpecs(d.DataDisks, d.volumeTypes)
+	if err != nil {
+		return err
 	}
+	d.dataDisks = dataDisks
 
 	// Tags
-	if d.extraTagsAll = flags.StringSlice(flagExtraTagsAll); !validateExtraTagsFormat(d.extraTagsAll) {
+	if d.ExtraTagsAll = flags.StringSlice(flagExtraTagsAll); !validateExtraTagsFormat(d.ExtraTagsAll) {
 		return fmt.Errorf("--%v have not the expected syntax", flagExtraTagsAll)
 	}
 
-	if d.extraTagsInstances = flags.StringSlice(flagExtraTagsInstances); !validateExtraTagsFormat(d.extraTagsInstances) {
+	if d.ExtraTagsInstances = flags.StringSlice(flagExtraTagsInstances); !validateExtraTagsFormat(d.ExtraTagsInstances) {
 		return fmt.Errorf("--%v have not the expected syntax", flagExtraTagsInstances)
 	}
 
-	d.tagK8sNodeName = flags.Bool(flagK8sNodeNameTag)
+	d.TagK8sNodeName = flags.Bool(flagK8sNodeNameTag)
+
+	// Retry policy
+	if d.RetryMaxAttempts = flags.Int(flagRetryMaxAttempts); d.RetryMaxAttempts <= 0 {
+		return fmt.Errorf("the retry max attempts (%v) is not accepted, it must be > 0", d.RetryMaxAttempts)
+	}
+
+	if d.RetryMaxDelay = flags.Int(flagRetryMaxDelay); d.RetryMaxDelay <= 0 {
+		return fmt.Errorf("the retry max delay (%v) is not accepted, it must be > 0", d.RetryMaxDelay)
+	}
+
+	statusCodes, err := parseStatusCodes(flags.StringSlice(flagRetryStatusCodes))
+	if err != nil {
+		return fmt.Errorf("--%v have not the expected syntax: %v", flagRetryStatusCodes, err)
+	}
+	d.RetryStatusCodes = statusCodes
+
+	// Rate limit
+	if d.RateLimit = flags.Int(flagRateLimit); d.RateLimit < 0 {
+		return fmt.Errorf("the rate limit (%v) is not accepted, it must be >= 0", d.RateLimit)
+	}
+
+	if d.RateLimitShared = flags.Bool(flagRateLimitShared); d.RateLimitShared && runtime.GOOS == "windows" {
+		return fmt.Errorf("--%v is not supported on Windows", flagRateLimitShared)
+	}
 
 	// Security Groups
-	d.securityGroupIds = flags.StringSlice(flagSecurityGroupIds)
+	d.SecurityGroupIds = flags.StringSlice(flagSecurityGroupIds)
+
+	d.SecurityGroupRules = flags.StringSlice(flagSecurityGroupRules)
+	securityGroupRules, err := parseSecurityGroupRuleSpecs(d.SecurityGroupRules)
+	if err != nil {
+		return err
+	}
+	d.securityGroupRules = securityGroupRules
+
+	if len(d.SecurityGroupIds) > 0 && len(d.securityGroupRules) > 0 {
+		return fmt.Errorf("--%v applies to the security group created by the driver, it can not be used with --%v", flagSecurityGroupRules, flagSecurityGroupIds)
+	}
+
+	// User data
+	if d.UserDataFile = flags.String(flagUserDataFile); d.UserDataFile != "" {
+		if d.userData, err = readUserData(d.UserDataFile); err != nil {
+			return err
+		}
+	}
 
 	// Private or Public Cloud
-	d.subnetId = flags.String(flagSubnetId)
-	d.PublicCloud = len(d.subnetId) == 0
+	d.SubnetId = flags.String(flagSubnetId)
+	d.PublicCloud = len(d.SubnetId) == 0
+
+	// Additional NICs
+	d.ExtraNics = flags.StringSlice(flagNics)
+	extraNics, err := parseNicSpecs(d.ExtraNics)
+	if err != nil {
+		return err
+	}
+	d.extraNics = extraNics
+
+	if d.PublicCloud && len(d.extraNics) > 0 {
+		return fmt.Errorf("--%v requires --%v", flagNics, flagSubnetId)
+	}
+
+	// Private IPs
+	d.PrivateIp = flags.String(flagPrivateIp)
+	d.SecondaryIps = flags.StringSlice(flagSecondaryIps)
+	if err := parsePrivateIps(append(d.requestedPrivateIps(), d.extraNicPrivateIps()...)); err != nil {
+		return fmt.Errorf("the private IPs are not accepted (%v)", err)
+	}
+
+	if d.PublicCloud && d.PrivateIp != "" {
+		return fmt.Errorf("--%v requires --%v", flagPrivateIp, flagSubnetId)
+	}
+
+	if d.PrivateIp == "" && len(d.SecondaryIps) > 0 {
+		return fmt.Errorf("--%v requires --%v", flagSecondaryIps, flagPrivateIp)
+	}
+
+	// Placement
+	d.Subregion = flags.String(flagSubregion)
+	if d.Tenancy = flags.String(flagTenancy); !validateTenancy(d.Tenancy) {
+		return fmt.Errorf("the tenancy is not accepted (got: %s, expected: 'default'|'dedicated')", d.Tenancy)
+	}
+
+	if d.PlacementStrategy = flags.String(flagPlacementStrategy); !validatePlacementStrategy(d.PlacementStrategy) {
+		return fmt.Errorf("the placement strategy is not accepted (got: %s, expected: 'spread')", d.PlacementStrategy)
+	}
+	d.PlacementGroupTag = flags.String(flagPlacementGroupTag)
+	d.PlacementSubnetIds = flags.StringSlice(flagPlacementSubnetIds)
+
+	if d.PlacementStrategy == placementStrategySpread {
+		if d.PlacementGroupTag == "" || !validateExtraTagsFormat([]string{d.PlacementGroupTag}) {
+			return fmt.Errorf("--%v must be set to a tag <key=value> with the 'spread' strategy", flagPlacementGroupTag)
+		}
+
+		if d.Subregion != "" {
+			return fmt.Errorf("--%v can not be used with the 'spread' strategy", flagSubregion)
+		}
+
+		if len(d.extraNics) > 0 {
+			return fmt.Errorf("--%v can not be used with the 'spread' strategy", flagNics)
+		}
+
+		if !d.PublicCloud {
+			return fmt.Errorf("the 'spread' strategy needs several subnets, use --%v instead of --%v", flagPlacementSubnetIds, flagSubnetId)
+		}
+	}
+
+	d.LoadBalancerNames = flags.StringSlice(flagLoadBalancerNames)
+
+	d.PurgeOnRemove = flags.Bool(flagPurgeOnRemove)
+	d.SnapshotOnRemove = flags.Bool(flagSnapshotOnRemove)
 
 	// SSH
 	d.SSHKeyPath = d.GetSSHKeyPath()
@@ -665,11 +1174,11 @@ func (d *OscDriver) Start() error {
 			_, httpRes, response_error = oscApi.client.VmApi.StartVms(oscApi.context).StartVmsRequest(request).Execute()
 			return wrapError(response_error, httpRes)
 		},
-		defaultThrottlingRetryOption...,
+		oscApi.retryOptions...,
 	)
 
 	if err != nil {
-		return fmt.Errorf("Error while submitting the StartVm request: %s", getErrorInfo(err, httpRes))
+		return fmt.Errorf("Error while submitting the StartVm request: %w", err)
 	}
 
 	if err := d.waitForState(d.VmId, "running"); err != nil {
@@ -700,11 +1209,11 @@ func (d *OscDriver) innerStop(force bool) error {
 			_, httpRes, response_error = oscApi.client.VmApi.StopVms(oscApi.context).StopVmsRequest(request).Execute()
 			return wrapError(response_error, httpRes)
 		},
-		defaultThrottlingRetryOption...,
+		oscApi.retryOptions...,
 	)
 
 	if err != nil {
-		return fmt.Errorf("Error while submitting the StopVm request: %s", getErrorInfo(err, httpRes))
+		return fmt.Errorf("Error while submitting the StopVm request: %w", err)
 	}
 
 	if err := d.waitForState(d.VmId, "stopped"); err != nil {
@@ -724,11 +1233,19 @@ func (d *OscDriver) Kill() error {
 	return d.innerStop(true)
 }
 
-func validateDiskType(diskType string) bool {
-	switch diskType {
-	case "io1", "gp2", "standard":
-		return true
-	default:
-		return false
+func validateDiskType(volumeTypes volumeTypeCatalog, diskType string) bool {
+	_, ok := volumeTypes[diskType]
+	return ok
+}
+
+func parseStatusCodes(values []string) ([]int, error) {
+	var statusCodes []int
+	for _, value := range values {
+		statusCode, err := strconv.Atoi(value)
+		if err != nil || statusCode < 100 || statusCode > 599 {
+			return nil, fmt.Errorf("'%v' is not a valid HTTP status code", value)
+		}
+		statusCodes = append(statusCodes, statusCode)
 	}
+	return statusCodes, nil
 }
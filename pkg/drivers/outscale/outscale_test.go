@@ -3,6 +3,7 @@ package outscale
 import (
 	"os"
 	"testing"
+	"time"
 
 	"github.com/docker/machine/libmachine/drivers"
 	"github.com/stretchr/testify/assert"
@@ -74,10 +75,67 @@ func TestSetConfigDefaultRegion(t *testing.T) {
 }
 
 func TestDiskType(t *testing.T) {
-	assert.Equal(t, true, validateDiskType("io1"))
-	assert.Equal(t, true, validateDiskType("standard"))
-	assert.Equal(t, true, validateDiskType("gp2"))
-	assert.Equal(t, false, validateDiskType("notADiskType"))
+	assert.Equal(t, true, validateDiskType(defaultVolumeTypes, "io1"))
+	assert.Equal(t, true, validateDiskType(defaultVolumeTypes, "standard"))
+	assert.Equal(t, true, validateDiskType(defaultVolumeTypes, "gp2"))
+	assert.Equal(t, false, validateDiskType(defaultVolumeTypes, "notADiskType"))
+}
+
+func TestVolumeSpec(t *testing.T) {
+	assert.NoError(t, defaultVolumeTypes.validate("standard", 1, 0))
+	assert.NoError(t, defaultVolumeTypes.validate("gp2", 14901, 0))
+	assert.NoError(t, defaultVolumeTypes.validate("io1", 4, 1200))
+	assert.NoError(t, defaultVolumeTypes.validate("io1", 100, 13000))
+	assert.NoError(t, defaultVolumeTypes.validate("io1", 0, 5000))
+
+	errors := []struct {
+		diskType string
+		size     int32
+		iops     int32
+		expected string
+	}{
+		{"ssd", 10, 0, "the type 'ssd' is not accepted (expected: 'gp2'|'io1'|'standard')"},
+		{"gp2", 14902, 0, "the size (14902 GiB) of a 'gp2' volume is not accepted, it must be between 1 and 14901"},
+		{"io1", 3, 100, "the size (3 GiB) of a 'io1' volume is not accepted, it must be between 4 and 14901"},
+		{"standard", 10, 100, "the iops can not be set for a 'standard' volume"},
+		{"io1", 100, 0, "the iops (0) of a 'io1' volume is not accepted, it must be between 1 and 13000"},
+		{"io1", 100, 13001, "the iops (13001) of a 'io1' volume is not accepted, it must be between 1 and 13000"},
+		{"io1", 10, 3001, "the iops (3001) of a 'io1' volume is not accepted, it must be at most 300 per GiB (3000 for 10 GiB)"},
+	}
+	for _, e := range errors {
+		assert.EqualError(t, defaultVolumeTypes.validate(e.diskType, e.size, e.iops), e.expected)
+	}
+}
+
+func TestRootDiskIo1(t *testing.T) {
+	os.Clearenv()
+	driver := NewDriver("", "")
+
+	os.Setenv("OSC_ACCESS_KEY", "OSC_ACCESS_KEY")
+	os.Setenv("OSC_SECRET_KEY", "OSC_SECRET_KEY")
+
+	checkFlags := &drivers.CheckDriverOptions{
+		FlagsValues: map[string]interface{}{
+			flagRootDiskType:    "io1",
+			flagRootDiskSize:    10,
+			flagRootDiskIo1Iops: 5000,
+		},
+		CreateFlags: driver.GetCreateFlags(),
+	}
+
+	err := driver.SetConfigFromFlags(checkFlags)
+	assert.Error(t, err)
+	assert.Equal(t, "the root disk is not accepted: the iops (5000) of a 'io1' volume is not accepted, it must be at most 300 per GiB (3000 for 10 GiB)", err.Error())
+
+	checkFlags.FlagsValues[flagRootDiskSize] = 20
+	err = driver.SetConfigFromFlags(checkFlags)
+	assert.NoError(t, err)
+
+	// The iops are ignored for the other types
+	checkFlags.FlagsValues[flagRootDiskType] = "gp2"
+	checkFlags.FlagsValues[flagRootDiskSize] = 10
+	err = driver.SetConfigFromFlags(checkFlags)
+	assert.NoError(t, err)
 }
 
 func TestDiskSize(t *testing.T) {
@@ -120,10 +178,57 @@ func TestDiskIops(t *testing.T) {
 
 	err := driver.SetConfigFromFlags(checkFlags)
 	assert.Error(t, err)
-	assert.Equal(t, "the disk iops (-1) is not accepted, it must between 1 and 13000", err.Error())
+	assert.Equal(t, "the disk iops (-1) is not accepted, it must be > 0", err.Error())
 
 	checkFlags.FlagsValues[flagRootDiskIo1Iops] = 12
 	err = driver.SetConfigFromFlags(checkFlags)
 	assert.NoError(t, err)
 
+	// The limits come from the root disk type
+	checkFlags.FlagsValues[flagRootDiskType] = "io1"
+	checkFlags.FlagsValues[flagRootDiskIo1Iops] = 0
+	err = driver.SetConfigFromFlags(checkFlags)
+	assert.EqualError(t, err, "the root disk is not accepted: the iops (0) of a 'io1' volume is not accepted, it must be between 1 and 13000")
+
+	checkFlags.FlagsValues[flagVolumeTypes] = []string{"name=io2,size=4-16384,iops=100-64000"}
+	checkFlags.FlagsValues[flagRootDiskType] = "io2"
+	checkFlags.FlagsValues[flagRootDiskIo1Iops] = 50
+	err = driver.SetConfigFromFlags(checkFlags)
+	assert.EqualError(t, err, "the root disk is not accepted: the iops (50) of a 'io2' volume is not accepted, it must be between 100 and 64000")
+
+}
+
+func TestRetryOptions(t *testing.T) {
+	os.Clearenv()
+	driver := NewDriver("", "")
+
+	os.Setenv("OSC_ACCESS_KEY", "OSC_ACCESS_KEY")
+	os.Setenv("OSC_SECRET_KEY", "OSC_SECRET_KEY")
+
+	checkFlags := &drivers.CheckDriverOptions{
+		FlagsValues: map[string]interface{}{
+			flagRetryStatusCodes: []string{"429", "teapot"},
+		},
+		CreateFlags: driver.GetCreateFlags(),
+	}
+
+	err := driver.SetConfigFromFlags(checkFlags)
+	assert.Error(t, err)
+	assert.Equal(t, "--outscale-retry-status-codes have not the expected syntax: 'teapot' is not a valid HTTP status code", err.Error())
+
+	checkFlags.FlagsValues[flagRetryStatusCodes] = []string{"429", "502"}
+	checkFlags.FlagsValues[flagRetryMaxAttempts] = 5
+	checkFlags.FlagsValues[flagRetryMaxDelay] = 2
+	err = driver.SetConfigFromFlags(checkFlags)
+	assert.NoError(t, err)
+
+	policy := driver.retryPolicy()
+	assert.Equal(t, uint(5), policy.maxAttempts)
+	assert.Equal(t, 2*time.Second, policy.maxDelay)
+	assert.Equal(t, []int{429, 502}, policy.statusCodes)
+
+	checkFlags.FlagsValues[flagRetryMaxAttempts] = 0
+	err = driver.SetConfigFromFlags(checkFlags)
+	assert.Error(t, err)
+	assert.Equal(t, "the retry max attempts (0) is not accepted, it must be > 0", err.Error())
 }
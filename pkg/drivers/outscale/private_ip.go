@@ -0,0 +1,150 @@
+package outscale
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"net/http"
+
+	retry "github.com/avast/retry-go"
+	osc "github.com/outscale/osc-sdk-go/v2"
+)
+
+const (
+	// The first four addresses of a subnet are reserved by OUTSCALE
+	subnetReservedFirstIps = 4
+)
+
+func readNics(d *OscDriver, filters osc.FiltersNic) ([]osc.Nic, error) {
+	oscApi, err := d.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	request := osc.ReadNicsRequest{
+		Filters: &filters,
+	}
+
+	var httpRes *http.Response
+	var response osc.ReadNicsResponse
+	err = retry.Do(
+		func() error {
+			var response_error error
+			response, httpRes, response_error = oscApi.client.NicApi.ReadNics(oscApi.context).ReadNicsRequest(request).Execute()
+			return wrapError(response_error, httpRes)
+		},
+		oscApi.retryOptions...,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error while submitting the Nic read request: %w", err)
+	}
+
+	return response.GetNics(), nil
+}
+
+// parsePrivateIps checks that the IPs are valid and requested once
+func parsePrivateIps(ips []string) error {
+	seen := make(map[string]bool)
+	for _, ip := range ips {
+		parsedIp := net.ParseIP(ip)
+		if parsedIp == nil || parsedIp.To4() == nil {
+			return fmt.Errorf("'%v' is not a valid IPv4 address", ip)
+		}
+
+		if seen[ip] {
+			return fmt.Errorf("the IP '%v' is requested several times", ip)
+		}
+		seen[ip] = true
+	}
+	return nil
+}
+
+// privateIpProblems checks that the IPs can be assigned in the subnet
+func privateIpProblems(ips []string, subnet osc.Subnet) []string {
+	var problems []string
+
+	_, ipRange, err := net.ParseCIDR(subnet.GetIpRange())
+	if err != nil {
+		return []string{fmt.Sprintf("The IP range '%v' of the Subnet '%v' is not valid.", subnet.GetIpRange(), subnet.GetSubnetId())}
+	}
+
+	ones, bits := ipRange.Mask.Size()
+	size := uint32(1) << uint32(bits-ones)
+	first := binary.BigEndian.Uint32(ipRange.IP.To4())
+
+	for _, ip := range ips {
+		parsedIp := net.ParseIP(ip).To4()
+		if parsedIp == nil || !ipRange.Contains(parsedIp) {
+			problems = append(problems, fmt.Sprintf("The IP '%v' is not in the IP range '%v' of the Subnet '%v'.", ip, subnet.GetIpRange(), subnet.GetSubnetId()))
+			continue
+		}
+
+		offset := binary.BigEndian.Uint32(parsedIp) - first
+		if offset < subnetReservedFirstIps || offset == size-1 {
+			problems = append(problems, fmt.Sprintf("The IP '%v' is reserved in the Subnet '%v' (the first four addresses and the last one can not be used).", ip, subnet.GetSubnetId()))
+		}
+	}
+
+	return problems
+}
+
+// privateIpConflicts returns the IPs of the subnet already used by a NIC
+func privateIpConflicts(d *OscDriver, ips []string, subnetId string) ([]string, error) {
+	if len(ips) == 0 {
+		return nil, nil
+	}
+
+	nics, err := readNics(d, osc.FiltersNic{
+		SubnetIds:            &[]string{subnetId},
+		PrivateIpsPrivateIps: &ips,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	requested := make(map[string]bool)
+	for _, ip := range ips {
+		requested[ip] = true
+	}
+
+	var problems []string
+	for _, nic := range nics {
+		for _, privateIp := range nic.GetPrivateIps() {
+			if requested[privateIp.GetPrivateIp()] {
+				problems = append(problems, fmt.Sprintf("The IP '%v' is already used by the NIC '%v' in the Subnet '%v'.", privateIp.GetPrivateIp(), nic.GetNicId(), subnetId))
+			}
+		}
+	}
+
+	return problems, nil
+}
+
+// requestedPrivateIps returns the private IPs requested for the primary NIC,
+// the main one first
+func (d *OscDriver) requestedPrivateIps() []string {
+	if d.PrivateIp == "" {
+		return d.SecondaryIps
+	}
+	return append([]string{d.PrivateIp}, d.SecondaryIps...)
+}
+
+// primaryNicPrivateIps returns the private IPs requested for the primary NIC
+func (d *OscDriver) primaryNicPrivateIps() []osc.PrivateIpLight {
+	var privateIps []osc.PrivateIpLight
+	if d.PrivateIp != "" {
+		privateIps = append(privateIps, osc.PrivateIpLight{
+			IsPrimary: osc.PtrBool(true),
+			PrivateIp: osc.PtrString(d.PrivateIp),
+		})
+	}
+
+	for _, ip := range d.SecondaryIps {
+		privateIps = append(privateIps, osc.PrivateIpLight{
+			IsPrimary: osc.PtrBool(false),
+			PrivateIp: osc.PtrString(ip),
+		})
+	}
+
+	return privateIps
+}
@@ -0,0 +1,57 @@
+package outscale
+
+import (
+	"testing"
+
+	osc "github.com/outscale/osc-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePrivateIps(t *testing.T) {
+	assert.NoError(t, parsePrivateIps(nil))
+	assert.NoError(t, parsePrivateIps([]string{"10.0.1.10", "10.0.1.11"}))
+
+	assert.EqualError(t, parsePrivateIps([]string{"10.0.1"}), "'10.0.1' is not a valid IPv4 address")
+	assert.EqualError(t, parsePrivateIps([]string{"fd00::1"}), "'fd00::1' is not a valid IPv4 address")
+	assert.EqualError(t, parsePrivateIps([]string{"10.0.1.10", "10.0.1.10"}), "the IP '10.0.1.10' is requested several times")
+}
+
+func TestExtraNicPrivateIps(t *testing.T) {
+	d := NewDriver("test", "")
+	d.PrivateIp = "10.0.1.10"
+	d.extraNics = []nicSpec{{subnetId: "subnet-2", privateIp: "10.0.2.10"}, {subnetId: "subnet-3"}}
+	assert.Equal(t, []string{"10.0.2.10"}, d.extraNicPrivateIps())
+
+	// An IP can not be requested for the machine and for a NIC
+	d.extraNics = append(d.extraNics, nicSpec{subnetId: "subnet-1", privateIp: "10.0.1.10"})
+	assert.EqualError(t, parsePrivateIps(append(d.requestedPrivateIps(), d.extraNicPrivateIps()...)), "the IP '10.0.1.10' is requested several times")
+}
+
+func TestPrivateIpProblems(t *testing.T) {
+	subnet := osc.Subnet{
+		SubnetId: osc.PtrString("subnet-1"),
+		IpRange:  osc.PtrString("10.0.1.0/24"),
+	}
+
+	assert.Empty(t, privateIpProblems([]string{"10.0.1.4", "10.0.1.254"}, subnet))
+
+	assert.Equal(t, []string{
+		"The IP '10.0.2.10' is not in the IP range '10.0.1.0/24' of the Subnet 'subnet-1'.",
+		"The IP '10.0.1.1' is reserved in the Subnet 'subnet-1' (the first four addresses and the last one can not be used).",
+		"The IP '10.0.1.255' is reserved in the Subnet 'subnet-1' (the first four addresses and the last one can not be used).",
+	}, privateIpProblems([]string{"10.0.2.10", "10.0.1.1", "10.0.1.255"}, subnet))
+}
+
+func TestPrimaryNicPrivateIps(t *testing.T) {
+	d := NewDriver("test", "")
+	assert.Empty(t, d.requestedPrivateIps())
+	assert.Empty(t, d.primaryNicPrivateIps())
+
+	d.PrivateIp = "10.0.1.10"
+	d.SecondaryIps = []string{"10.0.1.11"}
+	assert.Equal(t, []string{"10.0.1.10", "10.0.1.11"}, d.requestedPrivateIps())
+	assert.Equal(t, []osc.PrivateIpLight{
+		{IsPrimary: osc.PtrBool(true), PrivateIp: osc.PtrString("10.0.1.10")},
+		{IsPrimary: osc.PtrBool(false), PrivateIp: osc.PtrString("10.0.1.11")},
+	}, d.primaryNicPrivateIps())
+}
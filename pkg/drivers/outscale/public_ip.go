@@ -29,11 +29,11 @@ func createPublicIp(d *OscDriver) error {
 			response, httpRes, response_error = oscApi.client.PublicIpApi.CreatePublicIp(oscApi.context).CreatePublicIpRequest(request).Execute()
 			return wrapError(response_error, httpRes)
 		},
-		defaultThrottlingRetryOption...,
+		oscApi.retryOptions...,
 	)
 
 	if err != nil {
-		return fmt.Errorf("Error while submitting the Public IP creation request: %s", getErrorInfo(err, httpRes))
+		return fmt.Errorf("Error while submitting the Public IP creation request: %w", err)
 	}
 
 	if !response.HasPublicIp() {
@@ -43,6 +43,10 @@ func createPublicIp(d *OscDriver) error {
 	d.IPAddress = response.PublicIp.GetPublicIp()
 	d.PublicIpId = response.PublicIp.GetPublicIpId()
 
+	if err := addMachineTag(d, d.PublicIpId); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -68,11 +72,11 @@ func linkPublicIp(d *OscDriver) error {
 			response, httpRes, response_error = oscApi.client.PublicIpApi.LinkPublicIp(oscApi.context).LinkPublicIpRequest(request).Execute()
 			return wrapError(response_error, httpRes)
 		},
-		defaultThrottlingRetryOption...,
+		oscApi.retryOptions...,
 	)
 
 	if err != nil {
-		return fmt.Errorf("Error while submitting the Public IP link request: %s", getErrorInfo(err, httpRes))
+		return fmt.Errorf("Error while submitting the Public IP link request: %w", err)
 	}
 
 	if !response.HasLinkPublicIpId() {
@@ -100,24 +104,101 @@ func deletePublicIp(d *OscDriver, resourceId string) error {
 		return nil
 	}
 
+	if err := unlinkPublicIp(d, resourceId); err != nil {
+		return err
+	}
+
 	request := osc.DeletePublicIpRequest{
 		PublicIpId: &resourceId,
 	}
 
+	// The Public IP stays in use until the unlink is effective
+	var httpRes *http.Response
+	err = retryWhileInUse(func() error {
+		return retry.Do(
+			func() error {
+				var response_error error
+				_, httpRes, response_error = oscApi.client.PublicIpApi.DeletePublicIp(oscApi.context).DeletePublicIpRequest(request).Execute()
+				return wrapError(response_error, httpRes)
+			},
+			oscApi.retryOptions...,
+		)
+	})
+
+	if err != nil {
+		return fmt.Errorf("Error while submitting the Public IP link deletion request: %w", err)
+	}
+
+	return nil
+
+}
+
+// unlinkPublicIp unlinks the Public IP if it is still linked to a VM
+func unlinkPublicIp(d *OscDriver, resourceId string) error {
+	// Get the client
+	oscApi, err := d.getClient()
+	if err != nil {
+		return err
+	}
+
+	publicIps, err := readPublicIps(d, osc.FiltersPublicIp{
+		PublicIpIds: &[]string{resourceId},
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(publicIps) == 0 || !publicIps[0].HasLinkPublicIpId() {
+		return nil
+	}
+
+	log.Debug("Unlinking the Public Ip")
+
+	request := osc.UnlinkPublicIpRequest{
+		LinkPublicIpId: publicIps[0].LinkPublicIpId,
+	}
+
 	var httpRes *http.Response
 	err = retry.Do(
 		func() error {
 			var response_error error
-			_, httpRes, response_error = oscApi.client.PublicIpApi.DeletePublicIp(oscApi.context).DeletePublicIpRequest(request).Execute()
+			_, httpRes, response_error = oscApi.client.PublicIpApi.UnlinkPublicIp(oscApi.context).UnlinkPublicIpRequest(request).Execute()
 			return wrapError(response_error, httpRes)
 		},
-		defaultThrottlingRetryOption...,
+		oscApi.retryOptions...,
 	)
 
-	if err != nil {
-		return fmt.Errorf("Error while submitting the Public IP link deletion request: %s", getErrorInfo(err, httpRes))
+	if err != nil && !errors.Is(err, ErrResourceNotFound) {
+		return fmt.Errorf("Error while submitting the Public IP unlink request: %w", err)
 	}
 
 	return nil
+}
+
+func readPublicIps(d *OscDriver, filters osc.FiltersPublicIp) ([]osc.PublicIp, error) {
+	oscApi, err := d.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	request := osc.ReadPublicIpsRequest{
+		Filters: &filters,
+	}
+
+	var httpRes *http.Response
+	var response osc.ReadPublicIpsResponse
+	err = retry.Do(
+		func() error {
+			var response_error error
+			response, httpRes, response_error = oscApi.client.PublicIpApi.ReadPublicIps(oscApi.context).ReadPublicIpsRequest(request).Execute()
+			return wrapError(response_error, httpRes)
+		},
+		oscApi.retryOptions...,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error while submitting the Public IP read request: %w", err)
+	}
 
+	return response.GetPublicIps(), nil
 }
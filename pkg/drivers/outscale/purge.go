@@ -0,0 +1,165 @@
+package outscale
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/docker/machine/libmachine/log"
+	osc "github.com/outscale/osc-sdk-go/v2"
+)
+
+const (
+	purgeOnRemoveEnvVar = "OUTSCALE_PURGE_ON_REMOVE"
+)
+
+// purgeOnRemove reports whether Remove must also look for the resources of
+// the machine which are not stored in its config. It can be requested at
+// creation or when removing the machine through the environment.
+func (d *OscDriver) purgeOnRemove() bool {
+	if d.PurgeOnRemove {
+		return true
+	}
+
+	purge, _ := strconv.ParseBool(os.Getenv(purgeOnRemoveEnvVar))
+	return purge
+}
+
+// purge deletes every resource tagged with the id of the machine, and its
+// keypair found by name. The machines created before the ids existed are
+// purged by purgeWithoutMachineId.
+func (d *OscDriver) purge() error {
+	if d.MachineId == "" {
+		return d.purgeWithoutMachineId()
+	}
+
+	log.Infof("Looking for the resources of the machine '%v' to purge", d.GetMachineName())
+
+	var errs MultiError
+	tags := []string{d.machineIdTag()}
+
+	vms, err := readVms(d, osc.FiltersVm{
+		Tags: &tags,
+	})
+	if err != nil {
+		return err
+	}
+	for _, vm := range vms {
+		if vm.GetState() == "terminated" {
+			continue
+		}
+
+		log.Infof("Purging the VM '%v'", vm.GetVmId())
+		if err := ignoreNotFound(deleteVm(d, vm.GetVmId()), "VM", vm.GetVmId()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// The NICs created with delete-on-termination=false survive the machine
+	nics, err := readNics(d, osc.FiltersNic{
+		Tags: &tags,
+	})
+	if err != nil {
+		return err
+	}
+	for _, nic := range nics {
+		if d.isKeptNic(nic.GetNicId()) {
+			continue
+		}
+
+		log.Infof("Purging the NIC '%v'", nic.GetNicId())
+		if err := ignoreNotFound(deleteNic(d, nic.GetNicId()), "NIC", nic.GetNicId()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	publicIps, err := readPublicIps(d, osc.FiltersPublicIp{
+		Tags: &tags,
+	})
+	if err != nil {
+		return err
+	}
+	for _, publicIp := range publicIps {
+		log.Infof("Purging the public IP '%v'", publicIp.GetPublicIpId())
+		if err := ignoreNotFound(deletePublicIp(d, publicIp.GetPublicIpId()), "public IP", publicIp.GetPublicIpId()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	securityGroups, err := readSecurityGroups(d, osc.FiltersSecurityGroup{
+		Tags: &tags,
+	})
+	if err != nil {
+		return err
+	}
+	for _, securityGroup := range securityGroups {
+		log.Infof("Purging the security group '%v'", securityGroup.GetSecurityGroupId())
+		if err := ignoreNotFound(deleteSecurityGroup(d, securityGroup.GetSecurityGroupId()), "security group", securityGroup.GetSecurityGroupId()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	keypairs, err := readKeypairs(d, osc.FiltersKeypair{
+		KeypairNames: &[]string{d.keypairName()},
+	})
+	if err != nil {
+		return err
+	}
+	for _, keypair := range keypairs {
+		log.Infof("Purging the keypair '%v'", keypair.GetKeypairName())
+		if err := ignoreNotFound(deleteKeyPair(d, keypair.GetKeypairName()), "keypair", keypair.GetKeypairName()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	volumes, err := readVolumes(d, osc.FiltersVolume{
+		Tags: &tags,
+	})
+	if err != nil {
+		return err
+	}
+	for _, volume := range volumes {
+		log.Infof("Purging the volume '%v'", volume.GetVolumeId())
+		if err := ignoreNotFound(deleteVolume(d, volume.GetVolumeId()), "volume", volume.GetVolumeId()); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
+
+// purgeWithoutMachineId deletes the VMs whose NICs use the security group
+// stored for a machine created before the ids existed, e.g. when the creation
+// failed before storing the VM. Its name may be used by other machines of the
+// account, so nothing else is looked for: the keypair and the security group
+// stored are deleted by Remove once the VMs are gone.
+func (d *OscDriver) purgeWithoutMachineId() error {
+	log.Warnf("The machine '%v' has no id, its resources can not be told apart from the ones of other machines by their tags: only the VMs using its security group are purged", d.GetMachineName())
+
+	if d.SecurityGroupId == "" {
+		return nil
+	}
+
+	nics, err := readNics(d, osc.FiltersNic{
+		SecurityGroupIds: &[]string{d.SecurityGroupId},
+	})
+	if err != nil {
+		return err
+	}
+
+	var errs MultiError
+	purged := map[string]bool{d.VmId: true}
+	for _, nic := range nics {
+		vmId := nic.LinkNic.GetVmId()
+		if vmId == "" || purged[vmId] {
+			continue
+		}
+		purged[vmId] = true
+
+		log.Infof("Purging the VM '%v' using the security group '%v'", vmId, d.SecurityGroupId)
+		if err := ignoreNotFound(deleteVm(d, vmId), "VM", vmId); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs.ErrorOrNil()
+}
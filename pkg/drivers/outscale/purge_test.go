@@ -0,0 +1,135 @@
+package outscale
+
+import (
+	"net/http"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/docker/machine/libmachine/drivers"
+	osc "github.com/outscale/osc-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMachineId(t *testing.T) {
+	id, err := newMachineId()
+	assert.NoError(t, err)
+	assert.Regexp(t, "^[0-9a-f]{32}$", id)
+
+	otherId, err := newMachineId()
+	assert.NoError(t, err)
+	assert.NotEqual(t, id, otherId)
+
+	driver := NewDriver("node1", "")
+	driver.MachineId = id
+	assert.Equal(t, "docker-machine-id="+id, driver.machineIdTag())
+	assert.Equal(t, "docker-machine-node1-"+id, driver.keypairName())
+}
+
+func TestPurgeWithoutMachineId(t *testing.T) {
+	// A machine created before the ids existed can not be told apart from
+	// the other machines with the same name: without a security group,
+	// nothing is looked for
+	driver := NewDriver("node1", "")
+	assert.NoError(t, driver.purge())
+}
+
+func TestRemoveLegacyMachine(t *testing.T) {
+	// The creation failed before the VM was stored
+	var mutex sync.Mutex
+	var nicSecurityGroups interface{}
+	var deletedVms []interface{}
+	vm := &fakeVm{vm: osc.Vm{VmId: osc.PtrString("i-leftover"), State: osc.PtrString("terminated")}}
+	driver, api := newFakeApiDriver(t, "node1", map[string]fakeApiHandler{
+		"ReadNics": func(request map[string]interface{}) (int, interface{}) {
+			nicSecurityGroups = request["Filters"].(map[string]interface{})["SecurityGroupIds"]
+			return http.StatusOK, osc.ReadNicsResponse{Nics: &[]osc.Nic{
+				{NicId: osc.PtrString("eni-1"), LinkNic: &osc.LinkNic{VmId: osc.PtrString("i-leftover")}},
+				{NicId: osc.PtrString("eni-2"), LinkNic: &osc.LinkNic{VmId: osc.PtrString("i-leftover")}},
+				{NicId: osc.PtrString("eni-3")},
+			}}
+		},
+		"DeleteVms": func(request map[string]interface{}) (int, interface{}) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			deletedVms = append(deletedVms, request["VmIds"].([]interface{})...)
+			return http.StatusOK, struct{}{}
+		},
+		"ReadVms":             vm.read,
+		"DeleteSecurityGroup": fakeApiOk(struct{}{}),
+		"DeleteKeypair":       fakeApiOk(struct{}{}),
+	})
+	driver.SecurityGroupId = "sg-12345678"
+	driver.KeypairName = "docker-machine-node1-1600000000"
+	driver.PurgeOnRemove = true
+
+	assert.NoError(t, driver.deleteResources())
+	assert.Equal(t, []interface{}{"sg-12345678"}, nicSecurityGroups)
+	assert.Equal(t, []interface{}{"i-leftover"}, deletedVms)
+
+	// The VM is deleted before the security group and the keypair stored
+	assert.Equal(t, []string{"ReadNics", "DeleteVms", "ReadVms", "DeleteSecurityGroup", "DeleteKeypair"}, api.called())
+}
+
+func TestSetConfigMachineId(t *testing.T) {
+	os.Clearenv()
+	driver := NewDriver("node1", "")
+	checkFlags := &drivers.CheckDriverOptions{
+		FlagsValues: map[string]interface{}{
+			flagAccessKey: "ACCESS_KEY",
+			flagSecretKey: "SECRET_KEY",
+		},
+		CreateFlags: driver.GetCreateFlags(),
+	}
+	assert.NoError(t, driver.SetConfigFromFlags(checkFlags))
+	assert.NotEmpty(t, driver.MachineId)
+
+	other := NewDriver("node1", "")
+	assert.NoError(t, other.SetConfigFromFlags(checkFlags))
+	assert.NotEqual(t, driver.MachineId, other.MachineId)
+}
+
+func TestPurgeOnRemove(t *testing.T) {
+	os.Clearenv()
+	driver := NewDriver("node1", "")
+	assert.False(t, driver.purgeOnRemove())
+
+	os.Setenv(purgeOnRemoveEnvVar, "true")
+	assert.True(t, driver.purgeOnRemove())
+
+	os.Clearenv()
+	driver.PurgeOnRemove = true
+	assert.True(t, driver.purgeOnRemove())
+}
+
+func TestPurgeNics(t *testing.T) {
+	var mutex sync.Mutex
+	var nicTags interface{}
+	var deletedNics []string
+	driver, _ := newFakeApiDriver(t, "node1", map[string]fakeApiHandler{
+		"ReadVms": fakeApiOk(osc.ReadVmsResponse{Vms: &[]osc.Vm{}}),
+		"ReadNics": func(request map[string]interface{}) (int, interface{}) {
+			nicTags = request["Filters"].(map[string]interface{})["Tags"]
+			return http.StatusOK, osc.ReadNicsResponse{Nics: &[]osc.Nic{
+				{NicId: osc.PtrString("eni-left")},
+				{NicId: osc.PtrString("eni-kept")},
+			}}
+		},
+		"DeleteNic": func(request map[string]interface{}) (int, interface{}) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			deletedNics = append(deletedNics, request["NicId"].(string))
+			return http.StatusOK, struct{}{}
+		},
+		"ReadPublicIps":      fakeApiOk(osc.ReadPublicIpsResponse{PublicIps: &[]osc.PublicIp{}}),
+		"ReadSecurityGroups": fakeApiOk(osc.ReadSecurityGroupsResponse{SecurityGroups: &[]osc.SecurityGroup{}}),
+		"ReadKeypairs":       fakeApiOk(osc.ReadKeypairsResponse{Keypairs: &[]osc.Keypair{}}),
+		"ReadVolumes":        fakeApiOk(osc.ReadVolumesResponse{Volumes: &[]osc.Volume{}}),
+	})
+	driver.MachineId = "0123456789abcdef"
+	driver.KeptNicIds = []string{"eni-kept"}
+
+	assert.NoError(t, driver.purge())
+	assert.Equal(t, []interface{}{"docker-machine-id=0123456789abcdef"}, nicTags)
+	assert.Equal(t, []string{"eni-left"}, deletedNics)
+}
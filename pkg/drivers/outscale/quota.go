@@ -0,0 +1,214 @@
+package outscale
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	retry "github.com/avast/retry-go"
+	"github.com/docker/machine/libmachine/log"
+	osc "github.com/outscale/osc-sdk-go/v2"
+)
+
+const (
+	globalQuotaType = "global"
+
+	// Names of the global quotas returned by ReadQuotas
+	vmQuotaName            = "vm_limit"
+	coreQuotaName          = "core_limit"
+	memoryQuotaName        = "memory_limit"
+	volumeQuotaName        = "volume_limit"
+	volumeSizeQuotaName    = "volume_size_limit"
+	publicIpQuotaName      = "public_ip_limit"
+	securityGroupQuotaName = "security_group_limit"
+)
+
+// quotaRequirement is the amount of a quota that the creation will consume
+type quotaRequirement struct {
+	resource string
+	name     string
+	amount   int32
+}
+
+func readQuotas(d *OscDriver, filters osc.FiltersQuota) ([]osc.QuotaTypes, error) {
+	oscApi, err := d.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	request := osc.ReadQuotasRequest{
+		Filters: &filters,
+	}
+
+	var httpRes *http.Response
+	var response osc.ReadQuotasResponse
+	err = retry.Do(
+		func() error {
+			var response_error error
+			response, httpRes, response_error = oscApi.client.QuotaApi.ReadQuotas(oscApi.context).ReadQuotasRequest(request).Execute()
+			return wrapError(response_error, httpRes)
+		},
+		oscApi.retryOptions...,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error while submitting the Quota read request: %w", err)
+	}
+
+	return response.GetQuotaTypes(), nil
+}
+
+// vmTypeResources returns the number of vCores and the memory in GiB of a
+// VM type
+func vmTypeResources(d *OscDriver, vmType string) (int32, int32, error) {
+	if match := tinaVmTypePattern.FindStringSubmatch(vmType); match != nil {
+		cores, _ := strconv.Atoi(match[2])
+		memory, _ := strconv.Atoi(match[3])
+		return int32(cores), int32(memory), nil
+	}
+
+	vmTypes, err := readVmTypes(d, osc.FiltersVmType{
+		VmTypeNames: &[]string{vmType},
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if len(vmTypes) == 0 {
+		return 0, 0, fmt.Errorf("The VM type '%v' has not been found", vmType)
+	}
+
+	return vmTypes[0].GetVcoreCount(), int32(math.Ceil(float64(vmTypes[0].GetMemorySize()))), nil
+}
+
+// quotaRequirements returns what the creation of the machine will consume
+func (d *OscDriver) quotaRequirements() ([]quotaRequirement, error) {
+	cores, memory, err := vmTypeResources(d, d.InstanceType)
+	if err != nil {
+		return nil, err
+	}
+
+	volumeSize, err := d.requestedVolumeSize()
+	if err != nil {
+		return nil, err
+	}
+
+	requirements := []quotaRequirement{
+		{resource: "VMs", name: vmQuotaName, amount: 1},
+		{resource: "vCores", name: coreQuotaName, amount: cores},
+		{resource: "memory (GiB)", name: memoryQuotaName, amount: memory},
+		{resource: "volumes", name: volumeQuotaName, amount: int32(1 + len(d.dataDisks))},
+		{resource: "volume capacity (GiB)", name: volumeSizeQuotaName, amount: volumeSize},
+	}
+
+	if d.PublicCloud {
+		requirements = append(requirements, quotaRequirement{resource: "public IPs", name: publicIpQuotaName, amount: 1})
+	}
+
+	if len(d.SecurityGroupIds) == 0 {
+		requirements = append(requirements, quotaRequirement{resource: "security groups", name: securityGroupQuotaName, amount: 1})
+	}
+
+	return requirements, nil
+}
+
+// requestedVolumeSize returns the capacity of the volumes of the machine. A
+// volume created from a snapshot without a size has the size of the snapshot.
+func (d *OscDriver) requestedVolumeSize() (int32, error) {
+	volumeSize := d.RootDiskSize
+
+	var snapshotIds []string
+	for _, disk := range d.dataDisks {
+		if disk.size == 0 && disk.snapshotId != "" {
+			snapshotIds = append(snapshotIds, disk.snapshotId)
+		}
+		volumeSize += disk.size
+	}
+
+	if len(snapshotIds) == 0 {
+		return volumeSize, nil
+	}
+
+	snapshots, err := readSnapshots(d, osc.FiltersSnapshot{
+		SnapshotIds: &snapshotIds,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	snapshotSizes := make(map[string]int32)
+	for _, snapshot := range snapshots {
+		snapshotSizes[snapshot.GetSnapshotId()] = snapshot.GetVolumeSize()
+	}
+
+	// The missing snapshots are reported by the compatibility check
+	for _, snapshotId := range snapshotIds {
+		volumeSize += snapshotSizes[snapshotId]
+	}
+
+	return volumeSize, nil
+}
+
+// checkQuotas fails when the creation of the machine would exceed one of the
+// quotas of the account
+func (d *OscDriver) checkQuotas() error {
+	requirements, err := d.quotaRequirements()
+	if err != nil {
+		return err
+	}
+
+	quotaTypes, err := readQuotas(d, osc.FiltersQuota{
+		QuotaTypes: &[]string{globalQuotaType},
+	})
+	if err != nil {
+		return err
+	}
+
+	quotas := make(map[string]osc.Quota)
+	for _, quotaType := range quotaTypes {
+		for _, quota := range quotaType.GetQuotas() {
+			quotas[quota.GetName()] = quota
+		}
+	}
+
+	return checkQuotaRequirements(requirements, quotas)
+}
+
+func checkQuotaRequirements(requirements []quotaRequirement, quotas map[string]osc.Quota) error {
+	var problems []string
+	for _, requirement := range requirements {
+		quota, ok := quotas[requirement.name]
+		if !ok {
+			log.Warnf("The quota '%v' limiting the %v has not been found, they are not checked", requirement.name, requirement.resource)
+			continue
+		}
+
+		if quota.GetUsedValue()+requirement.amount > quota.GetMaxValue() {
+			problems = append(problems, fmt.Sprintf("%v: %v requested but %v of %v already used (quota '%v')",
+				requirement.resource, requirement.amount, quota.GetUsedValue(), quota.GetMaxValue(), requirement.name))
+		}
+	}
+
+	if len(problems) > 0 {
+		return QuotaError{Problems: problems}
+	}
+
+	return nil
+}
+
+// QuotaError lists the quotas of the account that the creation of the
+// machine would exceed
+type QuotaError struct {
+	Problems []string
+}
+
+func (e QuotaError) Error() string {
+	return fmt.Sprintf("The creation of the machine would exceed the quotas of the account:\n  - %v", strings.Join(e.Problems, "\n  - "))
+}
+
+// Is makes QuotaError match ErrQuotaExceeded
+func (e QuotaError) Is(target error) bool {
+	return target == ErrQuotaExceeded
+}
@@ -0,0 +1,58 @@
+package outscale
+
+import (
+	"errors"
+	"testing"
+
+	osc "github.com/outscale/osc-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func newQuota(name string, used int32, max int32) osc.Quota {
+	return osc.Quota{
+		Name:      osc.PtrString(name),
+		UsedValue: osc.PtrInt32(used),
+		MaxValue:  osc.PtrInt32(max),
+	}
+}
+
+func TestCheckQuotaRequirements(t *testing.T) {
+	requirements := []quotaRequirement{
+		{resource: "VMs", name: "vm_limit", amount: 1},
+		{resource: "vCores", name: "core_limit", amount: 4},
+		{resource: "public IPs", name: "public_ip_limit", amount: 1},
+	}
+
+	quotas := map[string]osc.Quota{
+		"vm_limit":   newQuota("vm_limit", 19, 20),
+		"core_limit": newQuota("core_limit", 10, 20),
+	}
+	assert.NoError(t, checkQuotaRequirements(requirements, quotas))
+
+	quotas["vm_limit"] = newQuota("vm_limit", 20, 20)
+	quotas["core_limit"] = newQuota("core_limit", 18, 20)
+
+	err := checkQuotaRequirements(requirements, quotas)
+	assert.Error(t, err)
+	assert.True(t, errors.Is(err, ErrQuotaExceeded))
+	assert.Equal(t, "The creation of the machine would exceed the quotas of the account:\n"+
+		"  - VMs: 1 requested but 20 of 20 already used (quota 'vm_limit')\n"+
+		"  - vCores: 4 requested but 18 of 20 already used (quota 'core_limit')", err.Error())
+}
+
+func TestRequestedVolumeSize(t *testing.T) {
+	driver, _ := newFakeApiDriver(t, "node1", map[string]fakeApiHandler{
+		"ReadSnapshots": fakeApiOk(osc.ReadSnapshotsResponse{Snapshots: &[]osc.Snapshot{
+			{SnapshotId: osc.PtrString("snap-12345678"), VolumeSize: osc.PtrInt32(30)},
+		}}),
+	})
+	driver.RootDiskSize = 15
+	driver.dataDisks = []dataDiskSpec{
+		{size: 100, diskType: "gp2"},
+		{diskType: "gp2", snapshotId: "snap-12345678"},
+	}
+
+	volumeSize, err := driver.requestedVolumeSize()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(145), volumeSize)
+}
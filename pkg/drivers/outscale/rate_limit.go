@@ -0,0 +1,170 @@
+package outscale
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/machine/libmachine/log"
+)
+
+const (
+	rateLimitLockFile = "outscale-rate-limit.lock"
+)
+
+// bucketState is the content of a token bucket at a given time
+type bucketState struct {
+	tokens float64
+	last   time.Time
+}
+
+// reserve takes one token from the bucket and returns how long the caller
+// must wait before using it. The number of tokens can become negative, which
+// records the requests already waiting for a token.
+func (b *bucketState) reserve(now time.Time, rate float64, burst float64) time.Duration {
+	if b.last.IsZero() {
+		b.tokens = burst
+		b.last = now
+	}
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * rate
+		if b.tokens > burst {
+			b.tokens = burst
+		}
+		b.last = now
+	}
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / rate * float64(time.Second))
+}
+
+// rateLimiter is a token bucket limiting the number of requests per second
+// sent to the API. When a lock file is set, the bucket is stored in this file
+// so that all the driver processes using it share the same budget.
+type rateLimiter struct {
+	rate     float64
+	burst    float64
+	lockFile string
+
+	mutex sync.Mutex
+	state bucketState
+}
+
+func newRateLimiter(requestsPerSecond int, lockFile string) *rateLimiter {
+	return &rateLimiter{
+		rate:     float64(requestsPerSecond),
+		burst:    float64(requestsPerSecond),
+		lockFile: lockFile,
+	}
+}
+
+// Wait blocks until the request is allowed by the limiter
+func (l *rateLimiter) Wait(ctx context.Context) error {
+	delay, err := l.reserve(time.Now())
+	if err != nil {
+		return err
+	}
+
+	if delay <= 0 {
+		return nil
+	}
+
+	log.Debugf("Waiting %v before sending the request because of the rate limit", delay)
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l *rateLimiter) reserve(now time.Time) (time.Duration, error) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.lockFile == "" {
+		return l.state.reserve(now, l.rate, l.burst), nil
+	}
+
+	return l.reserveShared(now)
+}
+
+func (l *rateLimiter) reserveShared(now time.Time) (time.Duration, error) {
+	file, err := os.OpenFile(l.lockFile, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return 0, fmt.Errorf("Error while opening the rate limit lock file: %v", err)
+	}
+	defer file.Close()
+
+	if err := lockFile(file); err != nil {
+		return 0, fmt.Errorf("Error while locking the rate limit lock file: %v", err)
+	}
+	defer unlockFile(file)
+
+	content, err := os.ReadFile(l.lockFile)
+	if err != nil {
+		return 0, fmt.Errorf("Error while reading the rate limit lock file: %v", err)
+	}
+
+	// A corrupted or empty file only resets the bucket
+	state, _ := parseBucketState(string(content))
+	delay := state.reserve(now, l.rate, l.burst)
+
+	if err := file.Truncate(0); err != nil {
+		return 0, fmt.Errorf("Error while writing the rate limit lock file: %v", err)
+	}
+	if _, err := file.WriteAt([]byte(formatBucketState(state)), 0); err != nil {
+		return 0, fmt.Errorf("Error while writing the rate limit lock file: %v", err)
+	}
+
+	return delay, nil
+}
+
+func formatBucketState(state bucketState) string {
+	return fmt.Sprintf("%v %v", strconv.FormatFloat(state.tokens, 'f', -1, 64), state.last.UnixNano())
+}
+
+func parseBucketState(content string) (bucketState, error) {
+	fields := strings.Fields(content)
+	if len(fields) != 2 {
+		return bucketState{}, fmt.Errorf("unexpected content '%v'", content)
+	}
+
+	tokens, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return bucketState{}, err
+	}
+
+	last, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return bucketState{}, err
+	}
+
+	return bucketState{tokens: tokens, last: time.Unix(0, last)}, nil
+}
+
+// rateLimitedTransport waits for the rate limiter before sending each request
+type rateLimitedTransport struct {
+	limiter *rateLimiter
+	next    http.RoundTripper
+}
+
+func (t *rateLimitedTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(request.Context()); err != nil {
+		return nil, err
+	}
+	return t.next.RoundTrip(request)
+}
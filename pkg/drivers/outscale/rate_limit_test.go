@@ -0,0 +1,67 @@
+package outscale
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucketReserve(t *testing.T) {
+	now := time.Now()
+	state := bucketState{}
+
+	// The bucket starts full
+	assert.Equal(t, time.Duration(0), state.reserve(now, 2, 2))
+	assert.Equal(t, time.Duration(0), state.reserve(now, 2, 2))
+
+	// Then each request waits for its own token
+	assert.Equal(t, 500*time.Millisecond, state.reserve(now, 2, 2))
+	assert.Equal(t, time.Second, state.reserve(now, 2, 2))
+
+	// The tokens are refilled over time, up to the burst
+	assert.Equal(t, time.Duration(0), state.reserve(now.Add(time.Hour), 2, 2))
+	assert.Equal(t, 2.0-1, state.tokens)
+}
+
+func TestBucketStateFormat(t *testing.T) {
+	state := bucketState{tokens: -1.5, last: time.Unix(0, 1234567890)}
+
+	parsed, err := parseBucketState(formatBucketState(state))
+	assert.NoError(t, err)
+	assert.Equal(t, state.tokens, parsed.tokens)
+	assert.True(t, state.last.Equal(parsed.last))
+
+	_, err = parseBucketState("")
+	assert.Error(t, err)
+}
+
+func TestSharedRateLimiter(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the shared rate limit is not supported on Windows")
+	}
+
+	lockFile := filepath.Join(t.TempDir(), rateLimitLockFile)
+	now := time.Now()
+
+	// Two limiters using the same file share the same bucket
+	first := newRateLimiter(1, lockFile)
+	second := newRateLimiter(1, lockFile)
+
+	delay, err := first.reserve(now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), delay)
+
+	delay, err = second.reserve(now)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Second, delay)
+
+	content, err := os.ReadFile(lockFile)
+	assert.NoError(t, err)
+	state, err := parseBucketState(string(content))
+	assert.NoError(t, err)
+	assert.Equal(t, -1.0, state.tokens)
+}
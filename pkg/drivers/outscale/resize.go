@@ -0,0 +1,142 @@
+package outscale
+
+import (
+	"fmt"
+	"net/http"
+
+	retry "github.com/avast/retry-go"
+	"github.com/docker/machine/libmachine/log"
+	"github.com/docker/machine/libmachine/state"
+	osc "github.com/outscale/osc-sdk-go/v2"
+)
+
+// Resize changes the VM type of the machine and, when rootDiskSize is greater
+// than 0, grows its root volume. The VM is stopped during the operation and
+// started again if it was running, even when the resize fails. It is not
+// stopped when nothing changes.
+func (d *OscDriver) Resize(vmType string, rootDiskSize int32) (err error) {
+	if vmType == "" && rootDiskSize <= 0 {
+		return fmt.Errorf("Nothing to resize: no VM type nor root disk size requested")
+	}
+
+	if vmType != "" {
+		if err := validateVmType(d, vmType); err != nil {
+			return err
+		}
+	}
+
+	vms, err := readVms(d, osc.FiltersVm{
+		VmIds: &[]string{d.VmId},
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(vms) == 0 {
+		return fmt.Errorf("The VM '%v' has not been found", d.VmId)
+	}
+	vm := vms[0]
+
+	volumeId := ""
+	if rootDiskSize > 0 {
+		if volumeId, err = rootVolumeId(vm); err != nil {
+			return err
+		}
+
+		volumes, err := readVolumes(d, osc.FiltersVolume{
+			VolumeIds: &[]string{volumeId},
+		})
+		if err != nil {
+			return err
+		}
+
+		if len(volumes) == 0 {
+			return fmt.Errorf("The Volume '%v' has not been found", volumeId)
+		}
+
+		if rootDiskSize < volumes[0].GetSize() {
+			return fmt.Errorf("the root disk can not be shrunk (current size: %v, requested: %v)", volumes[0].GetSize(), rootDiskSize)
+		}
+
+		if rootDiskSize == volumes[0].GetSize() {
+			volumeId = ""
+		} else if err := d.validateVolumeSize(volumes[0], rootDiskSize); err != nil {
+			return err
+		}
+	}
+
+	changesVmType := vmType != "" && vmType != vm.GetVmType()
+	if !changesVmType && volumeId == "" {
+		log.Infof("The VM '%v' already has the requested type and root disk size, nothing to resize", d.VmId)
+		return nil
+	}
+
+	vmState, err := d.GetState()
+	if err != nil {
+		return err
+	}
+
+	wasRunning := vmState == state.Running
+	if wasRunning {
+		log.Infof("Stopping the VM '%v' to resize it", d.VmId)
+		if err := d.innerStop(false); err != nil {
+			return err
+		}
+
+		defer func() {
+			if startErr := d.Start(); startErr != nil {
+				if err == nil {
+					err = startErr
+					return
+				}
+				log.Warnf("Error while starting the VM again: %v", startErr)
+			}
+		}()
+	}
+
+	if changesVmType {
+		log.Infof("Changing the VM type from '%v' to '%v'", vm.GetVmType(), vmType)
+		if err := updateVmType(d, d.VmId, vmType); err != nil {
+			return err
+		}
+		d.InstanceType = vmType
+	}
+
+	if volumeId != "" {
+		log.Infof("Growing the root volume '%v' to %v GiB", volumeId, rootDiskSize)
+		if err := updateVolumeSize(d, volumeId, rootDiskSize); err != nil {
+			return err
+		}
+		d.RootDiskSize = rootDiskSize
+	}
+
+	return nil
+}
+
+func updateVmType(d *OscDriver, vmId string, vmType string) error {
+	oscApi, err := d.getClient()
+	if err != nil {
+		return err
+	}
+
+	request := osc.UpdateVmRequest{
+		VmId: vmId,
+	}
+	request.SetVmType(vmType)
+
+	var httpRes *http.Response
+	err = retry.Do(
+		func() error {
+			var response_error error
+			_, httpRes, response_error = oscApi.client.VmApi.UpdateVm(oscApi.context).UpdateVmRequest(request).Execute()
+			return wrapError(response_error, httpRes)
+		},
+		oscApi.retryOptions...,
+	)
+
+	if err != nil {
+		return fmt.Errorf("Error while submitting the Vm update request: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,114 @@
+package outscale
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	osc "github.com/outscale/osc-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+// resizeApiHandlers returns the handlers of a running VM with a 10 GiB root
+// volume, updateVm answering the update of its type
+func resizeApiHandlers(vm *fakeVm, updateVm fakeApiHandler) map[string]fakeApiHandler {
+	var mutex sync.Mutex
+	volume := osc.Volume{
+		VolumeId:   osc.PtrString("vol-root"),
+		VolumeType: osc.PtrString("gp2"),
+		Size:       osc.PtrInt32(10),
+	}
+
+	return map[string]fakeApiHandler{
+		"ReadVms":     vm.read,
+		"StopVms":     vm.setState("stopped"),
+		"StartVms":    vm.setState("running"),
+		"ReadVmTypes": fakeApiOk(osc.ReadVmTypesResponse{VmTypes: &[]osc.VmType{{VmTypeName: osc.PtrString("tinav5.c1r1p1")}}}),
+		"UpdateVm":    updateVm,
+		"ReadVolumes": func(map[string]interface{}) (int, interface{}) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			return http.StatusOK, osc.ReadVolumesResponse{Volumes: &[]osc.Volume{volume}}
+		},
+		"UpdateVolume": func(request map[string]interface{}) (int, interface{}) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			volume.SetSize(int32(request["Size"].(float64)))
+			return http.StatusOK, osc.UpdateVolumeResponse{Volume: &volume}
+		},
+	}
+}
+
+func newResizeVm() *fakeVm {
+	return &fakeVm{vm: osc.Vm{
+		VmId:           osc.PtrString("i-12345678"),
+		VmType:         osc.PtrString("tinav5.c1r2p1"),
+		State:          osc.PtrString("running"),
+		RootDeviceName: osc.PtrString("/dev/sda1"),
+		BlockDeviceMappings: &[]osc.BlockDeviceMappingCreated{
+			{DeviceName: osc.PtrString("/dev/sda1"), Bsu: &osc.BsuCreated{VolumeId: osc.PtrString("vol-root")}},
+		},
+	}}
+}
+
+func TestResize(t *testing.T) {
+	vm := newResizeVm()
+	driver, api := newFakeApiDriver(t, "node1", resizeApiHandlers(vm, fakeApiOk(struct{}{})))
+	driver.VmId = "i-12345678"
+
+	assert.NoError(t, driver.Resize("tinav5.c4r8p1", 20))
+	assert.Equal(t, "tinav5.c4r8p1", driver.InstanceType)
+	assert.Equal(t, int32(20), driver.RootDiskSize)
+	assert.Equal(t, "running", vm.vm.GetState())
+	assert.Subset(t, api.called(), []string{"StopVms", "UpdateVm", "UpdateVolume", "StartVms"})
+}
+
+func TestResizeRestartsOnFailure(t *testing.T) {
+	vm := newResizeVm()
+	updateVm := func(map[string]interface{}) (int, interface{}) {
+		return http.StatusBadRequest, fakeApiError("InvalidParameterValue")
+	}
+	driver, api := newFakeApiDriver(t, "node1", resizeApiHandlers(vm, updateVm))
+	driver.VmId = "i-12345678"
+	driver.InstanceType = "tinav5.c1r2p1"
+
+	err := driver.Resize("tinav5.c4r8p1", 0)
+	assert.ErrorContains(t, err, "Error while submitting the Vm update request")
+
+	// The VM has been started again and the config is unchanged
+	assert.Equal(t, "running", vm.vm.GetState())
+	assert.Contains(t, api.called(), "StartVms")
+	assert.Equal(t, "tinav5.c1r2p1", driver.InstanceType)
+}
+
+func TestResizeRefusesToShrink(t *testing.T) {
+	vm := newResizeVm()
+	driver, api := newFakeApiDriver(t, "node1", resizeApiHandlers(vm, fakeApiOk(struct{}{})))
+	driver.VmId = "i-12345678"
+
+	assert.EqualError(t, driver.Resize("", 5), "the root disk can not be shrunk (current size: 10, requested: 5)")
+	assert.NotContains(t, api.called(), "StopVms")
+
+	assert.EqualError(t, driver.Resize("", 0), "Nothing to resize: no VM type nor root disk size requested")
+}
+
+func TestResizeNothingToChange(t *testing.T) {
+	vm := newResizeVm()
+	driver, api := newFakeApiDriver(t, "node1", resizeApiHandlers(vm, fakeApiOk(struct{}{})))
+	driver.VmId = "i-12345678"
+
+	// The current type and size are requested
+	assert.NoError(t, driver.Resize("tinav5.c1r2p1", 10))
+	assert.NotContains(t, api.called(), "StopVms")
+	assert.Equal(t, "running", vm.vm.GetState())
+}
+
+func TestResizeVolumeTypeLimits(t *testing.T) {
+	vm := newResizeVm()
+	driver, api := newFakeApiDriver(t, "node1", resizeApiHandlers(vm, fakeApiOk(struct{}{})))
+	driver.VmId = "i-12345678"
+
+	// The limits are checked before the VM is stopped
+	assert.EqualError(t, driver.Resize("", 20000), "the size (20000 GiB) of a 'gp2' volume is not accepted, it must be between 1 and 14901")
+	assert.NotContains(t, api.called(), "StopVms")
+}
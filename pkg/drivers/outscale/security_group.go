@@ -38,11 +38,11 @@ func addSecurityGroupRule(d *OscDriver, sgId string, request *osc.CreateSecurity
 			response, httpRes, response_error = oscApi.client.SecurityGroupRuleApi.CreateSecurityGroupRule(oscApi.context).CreateSecurityGroupRuleRequest(*request).Execute()
 			return wrapError(response_error, httpRes)
 		},
-		defaultThrottlingRetryOption...,
+		oscApi.retryOptions...,
 	)
 
 	if err != nil {
-		return fmt.Errorf("Error while submitting the Security Group Rule creation request: %s", getErrorInfo(err, httpRes))
+		return fmt.Errorf("Error while submitting the Security Group Rule creation request: %w", err)
 	}
 
 	if !response.HasSecurityGroup() {
@@ -91,11 +91,11 @@ func createDefaultSecurityGroup(d *OscDriver) error {
 			response, httpRes, response_error = oscApi.client.SecurityGroupApi.CreateSecurityGroup(oscApi.context).CreateSecurityGroupRequest(request).Execute()
 			return wrapError(response_error, httpRes)
 		},
-		defaultThrottlingRetryOption...,
+		oscApi.retryOptions...,
 	)
 
 	if err != nil {
-		return fmt.Errorf("Error while submitting the Security Group creation request: %s", getErrorInfo(err, httpRes))
+		return fmt.Errorf("Error while submitting the Security Group creation request: %w", err)
 	}
 
 	if !response.HasSecurityGroup() {
@@ -104,6 +104,10 @@ func createDefaultSecurityGroup(d *OscDriver) error {
 
 	d.SecurityGroupId = response.SecurityGroup.GetSecurityGroupId()
 
+	if err := addMachineTag(d, d.SecurityGroupId); err != nil {
+		return err
+	}
+
 	// Add SSH rule
 	sshRuleRequest := buildSecurityGroupRule("tcp", "Inbound", d.SecurityGroupId, 22, 22, "0.0.0.0/0")
 	if err := addSecurityGroupRule(d, d.SecurityGroupId, sshRuleRequest); err != nil {
@@ -185,8 +189,13 @@ func createDefaultSecurityGroup(d *OscDriver) error {
 		return err
 	}
 
+	// Add the rules requested by the user
+	if err := addExtraSecurityGroupRules(d); err != nil {
+		return err
+	}
+
 	// Add extra tags
-	if err := addExtraTags(d, d.SecurityGroupId, d.extraTagsAll); err != nil {
+	if err := addExtraTags(d, d.SecurityGroupId, d.ExtraTagsAll); err != nil {
 		return err
 	}
 
@@ -211,36 +220,34 @@ func deleteSecurityGroup(d *OscDriver, resourceId string) error {
 		SecurityGroupId: &resourceId,
 	}
 
+	// The Security Group stays in use until the NICs of the VM are released
 	var httpRes *http.Response
-	err = retry.Do(
-		func() error {
-			var response_error error
-			_, httpRes, response_error = oscApi.client.SecurityGroupApi.DeleteSecurityGroup(oscApi.context).DeleteSecurityGroupRequest(request).Execute()
-			return wrapError(response_error, httpRes)
-		},
-		defaultThrottlingRetryOption...,
-	)
+	err = retryWhileInUse(func() error {
+		return retry.Do(
+			func() error {
+				var response_error error
+				_, httpRes, response_error = oscApi.client.SecurityGroupApi.DeleteSecurityGroup(oscApi.context).DeleteSecurityGroupRequest(request).Execute()
+				return wrapError(response_error, httpRes)
+			},
+			oscApi.retryOptions...,
+		)
+	})
 
 	if err != nil {
-		return fmt.Errorf("Error while submitting the Security Group deletion request: %s", getErrorInfo(err, httpRes))
+		return fmt.Errorf("Error while submitting the Security Group deletion request: %w", err)
 	}
 
 	return nil
 }
 
-func isSecurityGroupExist(d *OscDriver, sgId string) (bool, error) {
-	log.Debugf("Chcek that the Security Group '%v' exists", sgId)
-
-	// Get the client
+func readSecurityGroups(d *OscDriver, filters osc.FiltersSecurityGroup) ([]osc.SecurityGroup, error) {
 	oscApi, err := d.getClient()
 	if err != nil {
-		return false, err
+		return nil, err
 	}
 
 	request := osc.ReadSecurityGroupsRequest{
-		Filters: &osc.FiltersSecurityGroup{
-			SecurityGroupIds: &[]string{sgId},
-		},
+		Filters: &filters,
 	}
 
 	var httpRes *http.Response
@@ -251,12 +258,21 @@ func isSecurityGroupExist(d *OscDriver, sgId string) (bool, error) {
 			response, httpRes, response_error = oscApi.client.SecurityGroupApi.ReadSecurityGroups(oscApi.context).ReadSecurityGroupsRequest(request).Execute()
 			return wrapError(response_error, httpRes)
 		},
-		defaultThrottlingRetryOption...,
+		oscApi.retryOptions...,
 	)
 
 	if err != nil {
-		return false, fmt.Errorf("Error while submitting the Security Group Rule read request: %s", getErrorInfo(err, httpRes))
+		return nil, fmt.Errorf("Error while submitting the Security Group read request: %w", err)
 	}
 
-	return response.HasSecurityGroups() && (len(response.GetSecurityGroups()) == 1), nil
+	return response.GetSecurityGroups(), nil
+}
+
+// vmSecurityGroupIds returns the security groups requested for the VM, or the
+// one created by the driver when none is requested
+func (d *OscDriver) vmSecurityGroupIds() []string {
+	if len(d.SecurityGroupIds) > 0 {
+		return d.SecurityGroupIds
+	}
+	return []string{d.SecurityGroupId}
 }
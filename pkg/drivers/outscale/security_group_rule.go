@@ -0,0 +1,108 @@
+package outscale
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/docker/machine/libmachine/log"
+)
+
+// securityGroupRuleSpec describes an additional inbound rule of the security
+// group created by the driver, given as
+// protocol=<tcp|udp>,ports=<port>|<from>-<to>,ip-range=<cidr>
+type securityGroupRuleSpec struct {
+	protocol string
+	fromPort int32
+	toPort   int32
+	ipRange  string
+}
+
+func parseSecurityGroupRuleSpec(spec string) (securityGroupRuleSpec, error) {
+	rule := securityGroupRuleSpec{
+		ipRange: "0.0.0.0/0",
+	}
+
+	for _, field := range strings.Split(spec, ",") {
+		splittedField := strings.SplitN(field, "=", 2)
+		if len(splittedField) != 2 {
+			return rule, fmt.Errorf("the field '%v' does not have the syntax 'key=value'", field)
+		}
+		key := strings.TrimSpace(splittedField[0])
+		value := strings.TrimSpace(splittedField[1])
+
+		switch key {
+		case "protocol":
+			if value != "tcp" && value != "udp" {
+				return rule, fmt.Errorf("the protocol '%v' is not accepted (expected: 'tcp'|'udp')", value)
+			}
+			rule.protocol = value
+		case "ports":
+			fromPort, toPort, err := parsePortRange(value)
+			if err != nil {
+				return rule, err
+			}
+			rule.fromPort, rule.toPort = fromPort, toPort
+		case "ip-range":
+			if _, _, err := net.ParseCIDR(value); err != nil {
+				return rule, fmt.Errorf("'%v' is not a valid CIDR", value)
+			}
+			rule.ipRange = value
+		default:
+			return rule, fmt.Errorf("the key '%v' is unknown (expected: 'protocol'|'ports'|'ip-range')", key)
+		}
+	}
+
+	if rule.protocol == "" {
+		return rule, fmt.Errorf("the protocol is required")
+	}
+
+	if rule.fromPort == 0 {
+		return rule, fmt.Errorf("the ports are required")
+	}
+
+	return rule, nil
+}
+
+func parseSecurityGroupRuleSpecs(specs []string) ([]securityGroupRuleSpec, error) {
+	var rules []securityGroupRuleSpec
+	for _, spec := range specs {
+		rule, err := parseSecurityGroupRuleSpec(spec)
+		if err != nil {
+			return nil, fmt.Errorf("the security group rule '%v' is not valid: %v", spec, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// parsePortRange parses <port> or <from>-<to>
+func parsePortRange(value string) (int32, int32, error) {
+	var fromPort, toPort int32
+	var err error
+	if strings.Contains(value, "-") {
+		fromPort, toPort, err = parseRange(value)
+	} else {
+		fromPort, err = parsePositiveInt32(value)
+		toPort = fromPort
+	}
+
+	if err != nil || toPort > 65535 {
+		return 0, 0, fmt.Errorf("the ports '%v' are not accepted, they must be a port or a range between 1 and 65535", value)
+	}
+
+	return fromPort, toPort, nil
+}
+
+// addExtraSecurityGroupRules adds the rules requested by the user to the
+// security group created by the driver
+func addExtraSecurityGroupRules(d *OscDriver) error {
+	for _, rule := range d.securityGroupRules {
+		ruleRequest := buildSecurityGroupRule(rule.protocol, "Inbound", d.SecurityGroupId, rule.fromPort, rule.toPort, rule.ipRange)
+		if err := addSecurityGroupRule(d, d.SecurityGroupId, ruleRequest); err != nil {
+			log.Errorf("Error while adding the %v rule %v-%v from %v in the SecurityGroup", rule.protocol, rule.fromPort, rule.toPort, rule.ipRange)
+			return err
+		}
+	}
+	return nil
+}
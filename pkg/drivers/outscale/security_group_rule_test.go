@@ -0,0 +1,99 @@
+package outscale
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/docker/machine/libmachine/drivers"
+	osc "github.com/outscale/osc-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityGroupRuleSpec(t *testing.T) {
+	rule, err := parseSecurityGroupRuleSpec("protocol=udp,ports=53")
+	assert.NoError(t, err)
+	assert.Equal(t, securityGroupRuleSpec{protocol: "udp", fromPort: 53, toPort: 53, ipRange: "0.0.0.0/0"}, rule)
+
+	rule, err = parseSecurityGroupRuleSpec("protocol=tcp,ports=8080-8090,ip-range=10.0.0.0/8")
+	assert.NoError(t, err)
+	assert.Equal(t, securityGroupRuleSpec{protocol: "tcp", fromPort: 8080, toPort: 8090, ipRange: "10.0.0.0/8"}, rule)
+
+	_, err = parseSecurityGroupRuleSpec("protocol=tcp,ports=70000")
+	assert.EqualError(t, err, "the ports '70000' are not accepted, they must be a port or a range between 1 and 65535")
+
+	_, err = parseSecurityGroupRuleSpec("protocol=tcp")
+	assert.EqualError(t, err, "the ports are required")
+
+	_, err = parseSecurityGroupRuleSpec("protocol=tcp,ports=22,ip-range=10.0.0.0")
+	assert.EqualError(t, err, "'10.0.0.0' is not a valid CIDR")
+}
+
+func TestSecurityGroupRulesWithSecurityGroupIds(t *testing.T) {
+	driver := NewDriver("machine", "")
+	err := driver.SetConfigFromFlags(&drivers.CheckDriverOptions{
+		FlagsValues: map[string]interface{}{
+			flagAccessKey:          "ACCESS_KEY",
+			flagSecretKey:          "SECRET_KEY",
+			flagSecurityGroupIds:   []string{"sg-1"},
+			flagSecurityGroupRules: []string{"protocol=tcp,ports=80"},
+		},
+		CreateFlags: driver.GetCreateFlags(),
+	})
+	assert.EqualError(t, err, "--outscale-security-group-rule applies to the security group created by the driver, it can not be used with --outscale-security-group-ids")
+}
+
+func TestParseSecurityGroupRuleSpecs(t *testing.T) {
+	rules, err := parseSecurityGroupRuleSpecs([]string{"protocol=tcp,ports=80", "protocol=udp,ports=60000-61000"})
+	assert.NoError(t, err)
+	assert.Equal(t, []securityGroupRuleSpec{
+		{protocol: "tcp", fromPort: 80, toPort: 80, ipRange: "0.0.0.0/0"},
+		{protocol: "udp", fromPort: 60000, toPort: 61000, ipRange: "0.0.0.0/0"},
+	}, rules)
+
+	_, err = parseSecurityGroupRuleSpecs([]string{"protocol=icmp,ports=1"})
+	assert.EqualError(t, err, "the security group rule 'protocol=icmp,ports=1' is not valid: the protocol 'icmp' is not accepted (expected: 'tcp'|'udp')")
+}
+
+func TestAddExtraSecurityGroupRules(t *testing.T) {
+	var mutex sync.Mutex
+	var requests []map[string]interface{}
+	driver, _ := newFakeApiDriver(t, "node1", map[string]fakeApiHandler{
+		"CreateSecurityGroupRule": func(request map[string]interface{}) (int, interface{}) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			requests = append(requests, request)
+			return http.StatusOK, osc.CreateSecurityGroupRuleResponse{SecurityGroup: &osc.SecurityGroup{}}
+		},
+	})
+	driver.SecurityGroupId = "sg-12345678"
+	driver.securityGroupRules = []securityGroupRuleSpec{
+		{protocol: "tcp", fromPort: 8080, toPort: 8090, ipRange: "10.0.0.0/8"},
+		{protocol: "udp", fromPort: 53, toPort: 53, ipRange: "0.0.0.0/0"},
+	}
+
+	assert.NoError(t, addExtraSecurityGroupRules(driver))
+	assert.Equal(t, []map[string]interface{}{
+		{"Flow": "Inbound", "SecurityGroupId": "sg-12345678", "IpProtocol": "tcp", "FromPortRange": float64(8080), "ToPortRange": float64(8090), "IpRange": "10.0.0.0/8"},
+		{"Flow": "Inbound", "SecurityGroupId": "sg-12345678", "IpProtocol": "udp", "FromPortRange": float64(53), "ToPortRange": float64(53), "IpRange": "0.0.0.0/0"},
+	}, requests)
+}
+
+func TestAddExtraSecurityGroupRulesError(t *testing.T) {
+	driver, api := newFakeApiDriver(t, "node1", map[string]fakeApiHandler{
+		"CreateSecurityGroupRule": func(map[string]interface{}) (int, interface{}) {
+			return http.StatusConflict, fakeApiError("Conflict")
+		},
+	})
+	driver.SecurityGroupId = "sg-12345678"
+	driver.securityGroupRules = []securityGroupRuleSpec{
+		{protocol: "tcp", fromPort: 80, toPort: 80, ipRange: "0.0.0.0/0"},
+		{protocol: "tcp", fromPort: 443, toPort: 443, ipRange: "0.0.0.0/0"},
+	}
+
+	err := addExtraSecurityGroupRules(driver)
+	assert.ErrorContains(t, err, "Error while submitting the Security Group Rule creation request")
+
+	// The creation stops at the first failure
+	assert.Equal(t, []string{"CreateSecurityGroupRule"}, api.called())
+}
@@ -0,0 +1,227 @@
+package outscale
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	retry "github.com/avast/retry-go"
+	"github.com/docker/machine/libmachine/log"
+	osc "github.com/outscale/osc-sdk-go/v2"
+)
+
+const (
+	snapshotOnRemoveEnvVar = "OUTSCALE_SNAPSHOT_ON_REMOVE"
+	snapshotDateTagKey     = "docker-machine-snapshot-date"
+
+	// Snapshots of big volumes take minutes to complete
+	defaultSnapshotDelay   = time.Duration(10) * time.Second
+	defaultSnapshotTimeout = time.Duration(60) * time.Minute
+)
+
+func readSnapshots(d *OscDriver, filters osc.FiltersSnapshot) ([]osc.Snapshot, error) {
+	oscApi, err := d.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	request := osc.ReadSnapshotsRequest{
+		Filters: &filters,
+	}
+
+	var httpRes *http.Response
+	var response osc.ReadSnapshotsResponse
+	err = retry.Do(
+		func() error {
+			var response_error error
+			response, httpRes, response_error = oscApi.client.SnapshotApi.ReadSnapshots(oscApi.context).ReadSnapshotsRequest(request).Execute()
+			return wrapError(response_error, httpRes)
+		},
+		oscApi.retryOptions...,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error while submitting the Snapshot read request: %w", err)
+	}
+
+	return response.GetSnapshots(), nil
+}
+
+// snapshotRequest is a volume of the machine created from a snapshot
+type snapshotRequest struct {
+	volume     string
+	snapshotId string
+	size       int32
+}
+
+func (d *OscDriver) snapshotRequests() []snapshotRequest {
+	var requests []snapshotRequest
+	if d.RootDiskSnapshotId != "" {
+		requests = append(requests, snapshotRequest{volume: "root disk", snapshotId: d.RootDiskSnapshotId, size: d.RootDiskSize})
+	}
+
+	for i, disk := range d.dataDisks {
+		if disk.snapshotId != "" {
+			requests = append(requests, snapshotRequest{volume: fmt.Sprintf("data disk %v", dataDiskDeviceName(i)), snapshotId: disk.snapshotId, size: disk.size})
+		}
+	}
+
+	return requests
+}
+
+// snapshotProblems checks that the snapshots exist in the region, are
+// completed and fit in the requested volumes
+func (d *OscDriver) snapshotProblems(snapshots []osc.Snapshot) []string {
+	var problems []string
+
+	found := make(map[string]osc.Snapshot)
+	for _, snapshot := range snapshots {
+		found[snapshot.GetSnapshotId()] = snapshot
+	}
+
+	for _, request := range d.snapshotRequests() {
+		snapshot, ok := found[request.snapshotId]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("The Snapshot '%v' of the %v does not exist in the region '%v' or is not shared with the account.", request.snapshotId, request.volume, d.Region))
+		case snapshot.GetState() != "completed":
+			problems = append(problems, fmt.Sprintf("The Snapshot '%v' of the %v is not completed (state: '%v'), wait for it or use another Snapshot.", request.snapshotId, request.volume, snapshot.GetState()))
+		case request.size > 0 && request.size < snapshot.GetVolumeSize():
+			problems = append(problems, fmt.Sprintf("The Snapshot '%v' of the %v needs a volume of at least %v GiB but %v GiB are requested.", request.snapshotId, request.volume, snapshot.GetVolumeSize(), request.size))
+		default:
+			log.Debugf("The Snapshot '%v' of the %v is completed.", request.snapshotId, request.volume)
+		}
+	}
+
+	return problems
+}
+
+// snapshotOnRemove reports whether Remove must snapshot the volumes of the
+// machine first. The environment, when set, overrides the stored option.
+func (d *OscDriver) snapshotOnRemove() bool {
+	if value, ok := os.LookupEnv(snapshotOnRemoveEnvVar); ok {
+		if snapshot, err := strconv.ParseBool(value); err == nil {
+			return snapshot
+		}
+	}
+
+	return d.SnapshotOnRemove
+}
+
+// Snapshot creates a snapshot of every volume attached to the VM, tagged with
+// the machine name and the date, and waits until they are completed. The
+// machine can be running. It returns the ids of the snapshots.
+func (d *OscDriver) Snapshot() ([]string, error) {
+	vms, err := readVms(d, osc.FiltersVm{
+		VmIds: &[]string{d.VmId},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(vms) == 0 {
+		return nil, fmt.Errorf("The VM '%v' has not been found: %w", d.VmId, ErrResourceNotFound)
+	}
+
+	date := time.Now().UTC().Format(time.RFC3339)
+
+	var snapshotIds []string
+	for _, blockDevice := range vms[0].GetBlockDeviceMappings() {
+		volumeId := blockDevice.Bsu.GetVolumeId()
+		description := fmt.Sprintf("Snapshot of %v of docker-machine %s", blockDevice.GetDeviceName(), d.GetMachineName())
+
+		snapshotId, err := createSnapshot(d, volumeId, description)
+		if err != nil {
+			return snapshotIds, err
+		}
+		snapshotIds = append(snapshotIds, snapshotId)
+
+		if err := addMachineTag(d, snapshotId); err != nil {
+			return snapshotIds, err
+		}
+
+		if err := addTag(d, snapshotId, snapshotDateTagKey, date); err != nil {
+			return snapshotIds, err
+		}
+	}
+
+	for _, snapshotId := range snapshotIds {
+		if err := waitForSnapshotCompleted(d, snapshotId); err != nil {
+			return snapshotIds, err
+		}
+	}
+
+	return snapshotIds, nil
+}
+
+func createSnapshot(d *OscDriver, volumeId string, description string) (string, error) {
+	log.Debugf("Creation of a Snapshot of the Volume '%v'", volumeId)
+
+	// Get the client
+	oscApi, err := d.getClient()
+	if err != nil {
+		return "", err
+	}
+
+	request := osc.CreateSnapshotRequest{
+		VolumeId:    osc.PtrString(volumeId),
+		Description: osc.PtrString(description),
+	}
+
+	var httpRes *http.Response
+	var response osc.CreateSnapshotResponse
+	err = retry.Do(
+		func() error {
+			var response_error error
+			response, httpRes, response_error = oscApi.client.SnapshotApi.CreateSnapshot(oscApi.context).CreateSnapshotRequest(request).Execute()
+			return wrapError(response_error, httpRes)
+		},
+		oscApi.retryOptions...,
+	)
+
+	if err != nil {
+		return "", fmt.Errorf("Error while submitting the Snapshot creation request: %w", err)
+	}
+
+	if !response.HasSnapshot() {
+		return "", errors.New("Error while creating the Snapshot: there is no Snapshot")
+	}
+
+	return response.Snapshot.GetSnapshotId(), nil
+}
+
+func waitForSnapshotCompleted(d *OscDriver, snapshotId string) error {
+	return retry.Do(
+		func() error {
+			snapshots, err := readSnapshots(d, osc.FiltersSnapshot{
+				SnapshotIds: &[]string{snapshotId},
+			})
+			if err != nil {
+				return err
+			}
+
+			if len(snapshots) == 0 {
+				return fmt.Errorf("The Snapshot '%v' has not been found", snapshotId)
+			}
+
+			switch snapshots[0].GetState() {
+			case "completed":
+				return nil
+			case "error":
+				return retry.Unrecoverable(fmt.Errorf("The Snapshot '%v' failed", snapshotId))
+			default:
+				return fmt.Errorf("The Snapshot is not (yet) completed (progress: %v%%)", snapshots[0].GetProgress())
+			}
+		},
+		retry.Attempts(uint(defaultSnapshotTimeout/defaultSnapshotDelay)),
+		retry.Delay(defaultSnapshotDelay),
+		retry.DelayType(retry.FixedDelay),
+		retry.LastErrorOnly(true),
+		retry.OnRetry(func(n uint, err error) {
+			log.Debugf("%v, retrying...", err)
+		}),
+	)
+}
@@ -0,0 +1,101 @@
+package outscale
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	osc "github.com/outscale/osc-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshotProblems(t *testing.T) {
+	snapshots := []osc.Snapshot{
+		{SnapshotId: osc.PtrString("snap-1"), State: osc.PtrString("completed"), VolumeSize: osc.PtrInt32(30)},
+		{SnapshotId: osc.PtrString("snap-2"), State: osc.PtrString("pending"), VolumeSize: osc.PtrInt32(10)},
+		{SnapshotId: osc.PtrString("snap-3"), State: osc.PtrString("completed"), VolumeSize: osc.PtrInt32(10)},
+	}
+
+	d := NewDriver("test", "")
+	d.Region = "eu-west-2"
+	d.RootDiskSize = 15
+	d.RootDiskSnapshotId = "snap-1"
+	d.dataDisks = []dataDiskSpec{
+		{size: 20},
+		{snapshotId: "snap-2"},
+		{snapshotId: "snap-3"},
+		{size: 10, snapshotId: "snap-4"},
+	}
+
+	assert.Equal(t, []string{
+		"The Snapshot 'snap-1' of the root disk needs a volume of at least 30 GiB but 15 GiB are requested.",
+		"The Snapshot 'snap-2' of the data disk /dev/xvdc is not completed (state: 'pending'), wait for it or use another Snapshot.",
+		"The Snapshot 'snap-4' of the data disk /dev/xvde does not exist in the region 'eu-west-2' or is not shared with the account.",
+	}, d.snapshotProblems(snapshots))
+
+	d.RootDiskSize = 30
+	d.dataDisks = nil
+	assert.Empty(t, d.snapshotProblems(snapshots))
+}
+
+func TestSnapshotOnRemove(t *testing.T) {
+	d := NewDriver("test", "")
+	assert.False(t, d.snapshotOnRemove())
+
+	d.SnapshotOnRemove = true
+	assert.True(t, d.snapshotOnRemove())
+
+	t.Setenv(snapshotOnRemoveEnvVar, "false")
+	assert.False(t, d.snapshotOnRemove())
+
+	d.SnapshotOnRemove = false
+	t.Setenv(snapshotOnRemoveEnvVar, "true")
+	assert.True(t, d.snapshotOnRemove())
+
+	t.Setenv(snapshotOnRemoveEnvVar, "maybe")
+	assert.False(t, d.snapshotOnRemove())
+}
+
+func TestRunCommandSnapshot(t *testing.T) {
+	storePath := t.TempDir()
+	machine := NewDriver("node1", storePath)
+	machine.VmId = "i-12345678"
+	writeMachineConfig(t, storePath, "node1", "outscale", machine)
+
+	vm := &fakeVm{vm: osc.Vm{
+		VmId:  osc.PtrString("i-12345678"),
+		State: osc.PtrString("running"),
+		BlockDeviceMappings: &[]osc.BlockDeviceMappingCreated{
+			{DeviceName: osc.PtrString("/dev/sda1"), Bsu: &osc.BsuCreated{VolumeId: osc.PtrString("vol-root")}},
+			{DeviceName: osc.PtrString("/dev/xvdb"), Bsu: &osc.BsuCreated{VolumeId: osc.PtrString("vol-data")}},
+		},
+	}}
+
+	var mutex sync.Mutex
+	var volumeIds []string
+	api := useFakeApiInCommands(t, map[string]fakeApiHandler{
+		"ReadVms": vm.read,
+		"CreateSnapshot": func(request map[string]interface{}) (int, interface{}) {
+			mutex.Lock()
+			defer mutex.Unlock()
+			volumeIds = append(volumeIds, request["VolumeId"].(string))
+			snapshotId := fmt.Sprintf("snap-%d", len(volumeIds))
+			return http.StatusOK, osc.CreateSnapshotResponse{Snapshot: &osc.Snapshot{SnapshotId: osc.PtrString(snapshotId)}}
+		},
+		"CreateTags": fakeApiOk(struct{}{}),
+		"ReadSnapshots": func(request map[string]interface{}) (int, interface{}) {
+			snapshotId := request["Filters"].(map[string]interface{})["SnapshotIds"].([]interface{})[0].(string)
+			return http.StatusOK, osc.ReadSnapshotsResponse{Snapshots: &[]osc.Snapshot{
+				{SnapshotId: osc.PtrString(snapshotId), State: osc.PtrString("completed")},
+			}}
+		},
+	})
+
+	var out bytes.Buffer
+	assert.NoError(t, RunCommand([]string{"snapshot", "--storage-path", storePath, "node1"}, &out))
+	assert.Equal(t, "snap-1\nsnap-2\n", out.String())
+	assert.Equal(t, []string{"vol-root", "vol-data"}, volumeIds)
+	assert.NotContains(t, api.called(), "StopVms")
+}
@@ -0,0 +1,131 @@
+package outscale
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	"github.com/docker/machine/libmachine/log"
+	osc "github.com/outscale/osc-sdk-go/v2"
+)
+
+const (
+	placementStrategyNone   = ""
+	placementStrategySpread = "spread"
+)
+
+func validatePlacementStrategy(strategy string) bool {
+	switch strategy {
+	case placementStrategyNone, placementStrategySpread:
+		return true
+	default:
+		return false
+	}
+}
+
+// applyPlacementStrategy chooses the subregion, and the subnet when a list is
+// given, of the machine according to the placement strategy
+func (d *OscDriver) applyPlacementStrategy() error {
+	if d.PlacementStrategy != placementStrategySpread {
+		return nil
+	}
+
+	// The candidates are the subregions, with their subnet if any
+	candidates := make(map[string]string)
+	if len(d.PlacementSubnetIds) > 0 {
+		subnets, err := readSubnets(d, osc.FiltersSubnet{
+			SubnetIds: &d.PlacementSubnetIds,
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, subnet := range subnets {
+			if other, ok := candidates[subnet.GetSubregionName()]; ok {
+				return fmt.Errorf("The subnets '%v' and '%v' are both in the subregion '%v', --%v expects one subnet per subregion", other, subnet.GetSubnetId(), subnet.GetSubregionName(), flagPlacementSubnetIds)
+			}
+			candidates[subnet.GetSubregionName()] = subnet.GetSubnetId()
+		}
+
+		if len(candidates) != len(d.PlacementSubnetIds) {
+			return fmt.Errorf("Some subnets of --%v do not exist (found %v of %v)", flagPlacementSubnetIds, len(candidates), len(d.PlacementSubnetIds))
+		}
+	} else {
+		subregions, err := readSubregions(d, osc.FiltersSubregion{})
+		if err != nil {
+			return err
+		}
+
+		for _, subregion := range subregions {
+			candidates[subregion.GetSubregionName()] = ""
+		}
+	}
+
+	if len(candidates) == 0 {
+		return fmt.Errorf("No subregion found to spread the machine")
+	}
+
+	// Count the VMs of the group in each subregion
+	vms, err := readVms(d, osc.FiltersVm{
+		Tags: &[]string{d.PlacementGroupTag},
+	})
+	if err != nil {
+		return err
+	}
+
+	counts := make(map[string]int)
+	for subregion := range candidates {
+		counts[subregion] = 0
+	}
+	for _, vm := range vms {
+		if vm.GetState() == "terminated" || vm.GetState() == "shutting-down" {
+			continue
+		}
+		subregion := vm.Placement.GetSubregionName()
+		if _, ok := counts[subregion]; ok {
+			counts[subregion]++
+		}
+	}
+
+	subregion := leastPopulatedSubregion(counts, d.GetMachineName())
+	log.Infof("Spreading the machine in the subregion '%v' (VMs of the group per subregion: %v)", subregion, counts)
+
+	d.Subregion = subregion
+	if subnetId := candidates[subregion]; subnetId != "" {
+		d.SubnetId = subnetId
+		d.PublicCloud = false
+	}
+
+	return nil
+}
+
+// leastPopulatedSubregion returns the subregion with the fewest VMs. Ties are
+// broken with the machine name, so that machines created at the same time do
+// not all choose the same subregion.
+func leastPopulatedSubregion(counts map[string]int, machineName string) string {
+	var leastPopulated []string
+	minCount := -1
+	for subregion, count := range counts {
+		switch {
+		case minCount < 0 || count < minCount:
+			minCount = count
+			leastPopulated = []string{subregion}
+		case count == minCount:
+			leastPopulated = append(leastPopulated, subregion)
+		}
+	}
+
+	sort.Strings(leastPopulated)
+
+	hash := fnv.New32a()
+	hash.Write([]byte(machineName))
+
+	return leastPopulated[hash.Sum32()%uint32(len(leastPopulated))]
+}
+
+// placementGroupTagKeyValue splits the group tag in its key and its value
+func (d *OscDriver) placementGroupTagKeyValue() (string, string) {
+	splittedTag := strings.SplitN(d.PlacementGroupTag, "=", 2)
+	return splittedTag[0], splittedTag[1]
+}
@@ -0,0 +1,70 @@
+package outscale
+
+import (
+	"os"
+	"testing"
+
+	"github.com/docker/machine/libmachine/drivers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLeastPopulatedSubregion(t *testing.T) {
+	counts := map[string]int{
+		"eu-west-2a": 2,
+		"eu-west-2b": 1,
+		"eu-west-2c": 3,
+	}
+	assert.Equal(t, "eu-west-2b", leastPopulatedSubregion(counts, "node1"))
+
+	// On ties, machines with different names are spread
+	counts = map[string]int{
+		"eu-west-2a": 0,
+		"eu-west-2b": 0,
+		"eu-west-2c": 0,
+	}
+	chosen := make(map[string]bool)
+	for _, name := range []string{"node1", "node2", "node3", "node4", "node5", "node6"} {
+		subregion := leastPopulatedSubregion(counts, name)
+		assert.Equal(t, subregion, leastPopulatedSubregion(counts, name))
+		chosen[subregion] = true
+	}
+	assert.Greater(t, len(chosen), 1)
+}
+
+func TestPlacementStrategyOptions(t *testing.T) {
+	os.Clearenv()
+	driver := NewDriver("", "")
+
+	os.Setenv("OSC_ACCESS_KEY", "OSC_ACCESS_KEY")
+	os.Setenv("OSC_SECRET_KEY", "OSC_SECRET_KEY")
+
+	checkFlags := &drivers.CheckDriverOptions{
+		FlagsValues: map[string]interface{}{
+			flagPlacementStrategy: "spread",
+		},
+		CreateFlags: driver.GetCreateFlags(),
+	}
+
+	err := driver.SetConfigFromFlags(checkFlags)
+	assert.Error(t, err)
+	assert.Equal(t, "--outscale-placement-group-tag must be set to a tag <key=value> with the 'spread' strategy", err.Error())
+
+	checkFlags.FlagsValues[flagPlacementGroupTag] = "cluster=prod"
+	checkFlags.FlagsValues[flagSubnetId] = "subnet-12345678"
+	err = driver.SetConfigFromFlags(checkFlags)
+	assert.Error(t, err)
+	assert.Equal(t, "the 'spread' strategy needs several subnets, use --outscale-placement-subnet-ids instead of --outscale-subnet-id", err.Error())
+
+	checkFlags.FlagsValues[flagSubnetId] = ""
+	checkFlags.FlagsValues[flagPlacementSubnetIds] = []string{"subnet-1", "subnet-2"}
+	err = driver.SetConfigFromFlags(checkFlags)
+	assert.NoError(t, err)
+
+	key, value := driver.placementGroupTagKeyValue()
+	assert.Equal(t, "cluster", key)
+	assert.Equal(t, "prod", value)
+
+	checkFlags.FlagsValues[flagPlacementStrategy] = "random"
+	err = driver.SetConfigFromFlags(checkFlags)
+	assert.Error(t, err)
+}
@@ -0,0 +1,99 @@
+package outscale
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// machineConfig is the part of the config.json of a machine describing its
+// driver
+type machineConfig struct {
+	DriverName string
+	Driver     json.RawMessage
+}
+
+// defaultStorePath returns the store of docker-machine: MACHINE_STORAGE_PATH
+// or ~/.docker/machine
+func defaultStorePath() string {
+	if storePath := os.Getenv("MACHINE_STORAGE_PATH"); storePath != "" {
+		return storePath
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".docker", "machine")
+}
+
+func machineConfigPath(storePath string, machineName string) string {
+	return filepath.Join(storePath, "machines", machineName, "config.json")
+}
+
+// loadMachine reads the driver of a machine of the store
+func loadMachine(storePath string, machineName string) (*OscDriver, error) {
+	content, err := os.ReadFile(machineConfigPath(storePath, machineName))
+	if err != nil {
+		return nil, fmt.Errorf("Error while reading the config of the machine '%v': %w", machineName, err)
+	}
+
+	var config machineConfig
+	if err := json.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("Error while parsing the config of the machine '%v': %w", machineName, err)
+	}
+
+	if config.DriverName != "outscale" {
+		return nil, fmt.Errorf("The machine '%v' is not an OUTSCALE machine (driver: '%v')", machineName, config.DriverName)
+	}
+
+	d := NewDriver(machineName, storePath)
+	if err := json.Unmarshal(config.Driver, d); err != nil {
+		return nil, fmt.Errorf("Error while parsing the driver config of the machine '%v': %w", machineName, err)
+	}
+
+	// The catalog is not stored, only the volume types added by the user
+	if d.volumeTypes, err = volumeTypesWith(d.VolumeTypes); err != nil {
+		return nil, fmt.Errorf("Error while parsing the driver config of the machine '%v': %w", machineName, err)
+	}
+
+	return d, nil
+}
+
+// saveMachine writes the driver back into the config.json of the machine,
+// keeping the other fields written by docker-machine
+func saveMachine(d *OscDriver) error {
+	path := machineConfigPath(d.StorePath, d.GetMachineName())
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("Error while reading the config of the machine '%v': %w", d.GetMachineName(), err)
+	}
+
+	var config map[string]json.RawMessage
+	if err := json.Unmarshal(content, &config); err != nil {
+		return fmt.Errorf("Error while parsing the config of the machine '%v': %w", d.GetMachineName(), err)
+	}
+
+	if config["Driver"], err = json.Marshal(d); err != nil {
+		return fmt.Errorf("Error while serializing the driver of the machine '%v': %w", d.GetMachineName(), err)
+	}
+
+	content, err = json.MarshalIndent(config, "", "    ")
+	if err != nil {
+		return fmt.Errorf("Error while serializing the config of the machine '%v': %w", d.GetMachineName(), err)
+	}
+
+	// The config is replaced at once so that it is never found half written
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, content, 0600); err != nil {
+		return fmt.Errorf("Error while writing the config of the machine '%v': %w", d.GetMachineName(), err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("Error while writing the config of the machine '%v': %w", d.GetMachineName(), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,56 @@
+package outscale
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSaveMachine(t *testing.T) {
+	storePath := t.TempDir()
+
+	d := NewDriver("node1", storePath)
+	d.VmId = "i-12345678"
+	d.InstanceType = "tinav5.c1r2p1"
+	writeMachineConfig(t, storePath, "node1", "outscale", d)
+
+	loaded, err := loadMachine(storePath, "node1")
+	assert.NoError(t, err)
+	assert.Equal(t, "i-12345678", loaded.VmId)
+
+	loaded.InstanceType = "tinav5.c4r8p1"
+	assert.NoError(t, saveMachine(loaded))
+
+	reloaded, err := loadMachine(storePath, "node1")
+	assert.NoError(t, err)
+	assert.Equal(t, "tinav5.c4r8p1", reloaded.InstanceType)
+	assert.Equal(t, "i-12345678", reloaded.VmId)
+
+	// The fields written by docker-machine are kept
+	content, err := os.ReadFile(machineConfigPath(storePath, "node1"))
+	assert.NoError(t, err)
+	var config map[string]interface{}
+	assert.NoError(t, json.Unmarshal(content, &config))
+	assert.Equal(t, "outscale", config["DriverName"])
+}
+
+func TestLoadMachineVolumeTypes(t *testing.T) {
+	storePath := t.TempDir()
+
+	d := NewDriver("node1", storePath)
+	d.VolumeTypes = []string{"name=gp3,size=1-16384,iops=3000-16000,iops-per-gib=500"}
+	writeMachineConfig(t, storePath, "node1", "outscale", d)
+
+	loaded, err := loadMachine(storePath, "node1")
+	assert.NoError(t, err)
+	assert.Contains(t, loaded.volumeTypes, "gp3")
+	assert.Contains(t, loaded.volumeTypes, "io1")
+}
+
+func TestDefaultStorePath(t *testing.T) {
+	os.Clearenv()
+	os.Setenv("MACHINE_STORAGE_PATH", "/tmp/store")
+	assert.Equal(t, "/tmp/store", defaultStorePath())
+}
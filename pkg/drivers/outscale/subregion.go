@@ -0,0 +1,62 @@
+package outscale
+
+import (
+	"fmt"
+	"net/http"
+
+	retry "github.com/avast/retry-go"
+	osc "github.com/outscale/osc-sdk-go/v2"
+)
+
+func readSubregions(d *OscDriver, filters osc.FiltersSubregion) ([]osc.Subregion, error) {
+	oscApi, err := d.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	request := osc.ReadSubregionsRequest{
+		Filters: &filters,
+	}
+
+	var httpRes *http.Response
+	var response osc.ReadSubregionsResponse
+	err = retry.Do(
+		func() error {
+			var response_error error
+			response, httpRes, response_error = oscApi.client.SubregionApi.ReadSubregions(oscApi.context).ReadSubregionsRequest(request).Execute()
+			return wrapError(response_error, httpRes)
+		},
+		oscApi.retryOptions...,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error while submitting the Subregion read request: %w", err)
+	}
+
+	return response.GetSubregions(), nil
+}
+
+func validateTenancy(tenancy string) bool {
+	switch tenancy {
+	case "default", "dedicated":
+		return true
+	default:
+		return false
+	}
+}
+
+// placement returns the placement of the VM requested by the user, or nil to
+// let the API choose
+func (d *OscDriver) placement() *osc.Placement {
+	if d.Subregion == "" && d.Tenancy == defaultTenancy {
+		return nil
+	}
+
+	placement := osc.Placement{}
+	if d.Subregion != "" {
+		placement.SetSubregionName(d.Subregion)
+	}
+	placement.SetTenancy(d.Tenancy)
+
+	return &placement
+}
@@ -0,0 +1,30 @@
+package outscale
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlacement(t *testing.T) {
+	driver := NewDriver("", "")
+	driver.Tenancy = defaultTenancy
+	assert.Nil(t, driver.placement())
+
+	driver.Subregion = "eu-west-2a"
+	placement := driver.placement()
+	assert.Equal(t, "eu-west-2a", placement.GetSubregionName())
+	assert.Equal(t, "default", placement.GetTenancy())
+
+	driver.Subregion = ""
+	driver.Tenancy = "dedicated"
+	placement = driver.placement()
+	assert.False(t, placement.HasSubregionName())
+	assert.Equal(t, "dedicated", placement.GetTenancy())
+}
+
+func TestTenancy(t *testing.T) {
+	assert.Equal(t, true, validateTenancy("default"))
+	assert.Equal(t, true, validateTenancy("dedicated"))
+	assert.Equal(t, false, validateTenancy("host"))
+}
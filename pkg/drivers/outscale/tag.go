@@ -1,6 +1,8 @@
 package outscale
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"strings"
@@ -10,6 +12,44 @@ import (
 	osc "github.com/outscale/osc-sdk-go/v2"
 )
 
+const (
+	// machineTagKey is set to the machine name on every resource created by
+	// the driver, so they can be found again when the stored ids are lost
+	machineTagKey = "docker-machine-name"
+
+	// machineIdTagKey is set to the id of the machine, unlike its name unique
+	// across the stores and the users sharing an account. It identifies the
+	// resources to purge.
+	machineIdTagKey = "docker-machine-id"
+)
+
+// newMachineId returns a random id for a new machine
+func newMachineId() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("Error while generating the machine id: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// machineIdTag returns the tag filter <key>=<value> matching the resources of
+// the machine
+func (d *OscDriver) machineIdTag() string {
+	return fmt.Sprintf("%s=%s", machineIdTagKey, d.MachineId)
+}
+
+func addMachineTag(d *OscDriver, resourceId string) error {
+	if err := addTag(d, resourceId, machineTagKey, d.GetMachineName()); err != nil {
+		return err
+	}
+
+	// The machines created before the ids existed only have the name tag
+	if d.MachineId == "" {
+		return nil
+	}
+	return addTag(d, resourceId, machineIdTagKey, d.MachineId)
+}
+
 func addTag(d *OscDriver, resourceId string, key string, value string) error {
 	log.Debugf("Add tag {\"%s\": \"%s\"} to %s", key, value, resourceId)
 
@@ -38,11 +78,11 @@ func addTag(d *OscDriver, resourceId string, key string, value string) error {
 			_, httpRes, response_error = oscApi.client.TagApi.CreateTags(oscApi.context).CreateTagsRequest(request).Execute()
 			return wrapError(response_error, httpRes)
 		},
-		defaultThrottlingRetryOption...,
+		oscApi.retryOptions...,
 	)
 
 	if err != nil {
-		return fmt.Errorf("Error while submitting the CreateTag request: %s", getErrorInfo(err, httpRes))
+		return fmt.Errorf("Error while submitting the CreateTag request: %w", err)
 	}
 
 	return nil
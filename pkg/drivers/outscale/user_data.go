@@ -0,0 +1,27 @@
+package outscale
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+)
+
+const (
+	// The API limits the Base64-encoded user data to 500 KiB
+	maxUserDataSize = 500 * 1024
+)
+
+// readUserData returns the Base64-encoded content of the user data file
+func readUserData(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("Error while reading the user data: %w", err)
+	}
+
+	userData := base64.StdEncoding.EncodeToString(content)
+	if len(userData) > maxUserDataSize {
+		return "", fmt.Errorf("the user data '%v' is not accepted, it must be at most %v bytes once Base64-encoded (got: %v)", path, maxUserDataSize, len(userData))
+	}
+
+	return userData, nil
+}
@@ -0,0 +1,54 @@
+package outscale
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/docker/machine/libmachine/drivers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadUserData(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cloud-init.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("#cloud-config\n"), 0600))
+
+	userData, err := readUserData(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "I2Nsb3VkLWNvbmZpZwo=", userData)
+
+	_, err = readUserData(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "Error while reading the user data")
+}
+
+func TestReadUserDataSize(t *testing.T) {
+	// 3 bytes are encoded in 4 characters
+	path := filepath.Join(t.TempDir(), "user-data")
+	assert.NoError(t, os.WriteFile(path, []byte(strings.Repeat("a", maxUserDataSize/4*3)), 0600))
+
+	userData, err := readUserData(path)
+	assert.NoError(t, err)
+	assert.Len(t, userData, maxUserDataSize)
+
+	assert.NoError(t, os.WriteFile(path, []byte(strings.Repeat("a", maxUserDataSize/4*3+1)), 0600))
+	_, err = readUserData(path)
+	assert.EqualError(t, err, "the user data '"+path+"' is not accepted, it must be at most 512000 bytes once Base64-encoded (got: 512004)")
+}
+
+func TestUserDataFileFlag(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cloud-init.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("#cloud-config\n"), 0600))
+
+	driver := NewDriver("machine", "")
+	assert.NoError(t, driver.SetConfigFromFlags(&drivers.CheckDriverOptions{
+		FlagsValues: map[string]interface{}{
+			flagAccessKey:    "ACCESS_KEY",
+			flagSecretKey:    "SECRET_KEY",
+			flagUserDataFile: path,
+		},
+		CreateFlags: driver.GetCreateFlags(),
+	}))
+	assert.Equal(t, path, driver.UserDataFile)
+	assert.Equal(t, "I2Nsb3VkLWNvbmZpZwo=", driver.userData)
+}
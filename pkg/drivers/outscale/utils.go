@@ -3,7 +3,14 @@ package outscale
 import (
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"syscall"
 	"time"
 
 	retry "github.com/avast/retry-go"
@@ -16,23 +23,136 @@ const (
 	defaultReadDelay             = time.Duration(1) * time.Second
 	defaultThrottlingDelay       = time.Duration(15) * time.Second
 	defaultThrottlingMaxAttempts = 60
+	defaultDependencyDelay       = time.Duration(5) * time.Second
+	defaultDependencyTimeout     = time.Duration(5) * time.Minute
 )
 
 var (
-	defaultThrottlingRetryOption = []retry.Option{
-		retry.MaxJitter(defaultThrottlingDelay),
-		retry.DelayType(retry.RandomDelay),
-		retry.Attempts(defaultThrottlingMaxAttempts),
+	// Throtlling
+	ThrottlingErrors = []int{503, 429}
+)
+
+// retryPolicy describes how the calls to the API are retried when they fail
+// because of throttling or of a transient network issue
+type retryPolicy struct {
+	maxAttempts uint
+	maxDelay    time.Duration
+	statusCodes []int
+}
+
+func defaultRetryPolicy() retryPolicy {
+	return retryPolicy{
+		maxAttempts: defaultThrottlingMaxAttempts,
+		maxDelay:    defaultThrottlingDelay,
+		statusCodes: ThrottlingErrors,
+	}
+}
+
+func (p retryPolicy) options() []retry.Option {
+	return []retry.Option{
+		retry.MaxJitter(p.maxDelay),
+		retry.DelayType(p.retryAfterOrRandomDelay),
+		retry.Attempts(p.maxAttempts),
 		retry.OnRetry(func(n uint, err error) {
-			log.Debug("Retry number %v after throttling.", n)
+			log.Debugf("Retry number %v after error: %v", n, err)
 		}),
-		retry.RetryIf(isThrottlingError),
+		retry.RetryIf(p.isRetryable),
 		retry.LastErrorOnly(true),
 	}
+}
 
-	// Throtlling
-	ThrottlingErrors = []int{503, 429}
-)
+func (p retryPolicy) isRetryable(err error) bool {
+	cloudError, ok := err.(CloudError)
+	if !ok {
+		return false
+	}
+	if cloudError.httpRes != nil {
+		for _, errorCode := range p.statusCodes {
+			if errorCode == cloudError.httpRes.StatusCode {
+				return true
+			}
+		}
+		return false
+	}
+	return isTransientNetworkError(cloudError.cloudError) && isIdempotentCall(cloudError.cloudError)
+}
+
+// isTransientNetworkError reports whether the error is a timeout or a connection
+// closed by the remote end, both worth retrying
+func isTransientNetworkError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var netError net.Error
+	if errors.As(err, &netError) && netError.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// isIdempotentCall reports whether the request which failed can be sent
+// again without creating a resource twice, when its response was lost. Only
+// the Read* and Delete* operations, whose name ends the URL, are.
+func isIdempotentCall(err error) bool {
+	var urlError *url.Error
+	if !errors.As(err, &urlError) {
+		return false
+	}
+
+	requestUrl, err := url.Parse(urlError.URL)
+	if err != nil {
+		return false
+	}
+
+	operation := path.Base(requestUrl.Path)
+	return strings.HasPrefix(operation, "Read") || strings.HasPrefix(operation, "Delete")
+}
+
+// retryAfterOrRandomDelay waits for the duration asked by the Retry-After
+// header when the API sends one, up to the max delay of the policy, and a
+// random delay otherwise
+func (p retryPolicy) retryAfterOrRandomDelay(n uint, err error, config *retry.Config) time.Duration {
+	if delay, ok := retryAfterDelay(err); ok {
+		if delay > p.maxDelay {
+			return p.maxDelay
+		}
+		return delay
+	}
+	return retry.RandomDelay(n, err, config)
+}
+
+func retryAfterDelay(err error) (time.Duration, bool) {
+	cloudError, ok := err.(CloudError)
+	if !ok || cloudError.httpRes == nil {
+		return 0, false
+	}
+
+	header := cloudError.httpRes.Header.Get("Retry-After")
+	if header == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(header); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(header); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+
+	return 0, false
+}
 
 func (d *OscDriver) waitForState(vmId string, state string) error {
 	err := retry.Do(
@@ -52,10 +172,14 @@ func (d *OscDriver) waitForState(vmId string, state string) error {
 
 			readVmResponse, httpRes, err := oscApi.client.VmApi.ReadVms(oscApi.context).ReadVmsRequest(readVmRequest).Execute()
 			if err != nil {
-				return fmt.Errorf("Error while submitting the Vm read request: %s", getErrorInfo(err, httpRes))
+				return fmt.Errorf("Error while submitting the Vm read request: %w", wrapError(err, httpRes))
 			}
 
-			if !readVmResponse.HasVms() {
+			if len(readVmResponse.GetVms()) == 0 {
+				// A terminated VM is removed from the listing after a while
+				if state == "terminated" {
+					return nil
+				}
 				return errors.New("Error while reading the VM: there is no VM")
 			}
 
@@ -78,64 +202,61 @@ func (d *OscDriver) waitForState(vmId string, state string) error {
 	return nil
 }
 
-func isThrottlingError(err error) bool {
-	cloudError, ok := err.(CloudError)
-	if ! ok {
-		return false
-	}
-	if cloudError.httpRes != nil {
-		for _, errorCode := range ThrottlingErrors {
-			if errorCode == cloudError.httpRes.StatusCode {
-				return true
-			}
-		}
-	}
-	return false
+func cleanUp(d *OscDriver) {
+	d.deleteResources()
 }
 
-func cleanUp(d *OscDriver) {
-	d.Remove()
+// retryWhileInUse retries the deletion of a resource as long as the API
+// reports that it is still used by another one, up to defaultDependencyTimeout
+func retryWhileInUse(deletion retry.RetryableFunc) error {
+	return retry.Do(
+		deletion,
+		retry.Attempts(uint(defaultDependencyTimeout/defaultDependencyDelay)),
+		retry.Delay(defaultDependencyDelay),
+		retry.DelayType(retry.FixedDelay),
+		retry.OnRetry(func(n uint, err error) {
+			log.Debugf("The resource is still in use, retrying the deletion: %v", err)
+		}),
+		retry.RetryIf(func(err error) bool {
+			return errors.Is(err, ErrDependencyViolation)
+		}),
+		retry.LastErrorOnly(true),
+	)
 }
 
-func extractApiError(err error) (bool, *osc.ErrorResponse) {
-	genericError, ok := err.(osc.GenericOpenAPIError)
-	if ok {
-		errorsResponse, ok := genericError.Model().(osc.ErrorResponse)
-		if ok {
-			return true, &errorsResponse
-		}
-		return false, nil
+// ignoreNotFound treats a resource which does not exist anymore as removed
+func ignoreNotFound(err error, resource string, resourceId string) error {
+	if err != nil && errors.Is(err, ErrResourceNotFound) {
+		log.Warnf("The %v '%v' does not exist anymore, considering it as removed.", resource, resourceId)
+		return nil
 	}
-	return false, nil
+	return err
 }
 
-func getErrorInfo(err error, httpRes *http.Response) string {
-	if ok, apiError := extractApiError(err); ok {
-		return fmt.Sprintf("%v - '%v %v' - '%v'", httpRes.Status, apiError.GetErrors()[0].GetCode(), apiError.GetErrors()[0].GetType(), apiError.GetErrors()[0].GetDetails())
-	}
-	if httpRes != nil {
-		return httpRes.Status
+func readVms(d *OscDriver, filters osc.FiltersVm) ([]osc.Vm, error) {
+	oscApi, err := d.getClient()
+	if err != nil {
+		return nil, err
 	}
 
-	return fmt.Sprintf("%v", err)
-}
-
-type CloudError struct {
-	httpRes *http.Response
-	cloudError error
-}
+	request := osc.ReadVmsRequest{
+		Filters: &filters,
+	}
 
-func (e CloudError) Error() string {
-    return getErrorInfo(e.cloudError, e.httpRes)
-}
+	var httpRes *http.Response
+	var response osc.ReadVmsResponse
+	err = retry.Do(
+		func() error {
+			var response_error error
+			response, httpRes, response_error = oscApi.client.VmApi.ReadVms(oscApi.context).ReadVmsRequest(request).Execute()
+			return wrapError(response_error, httpRes)
+		},
+		oscApi.retryOptions...,
+	)
 
-func wrapError(err error, httpRes *http.Response) error {
-	if err == nil {
-		return nil
+	if err != nil {
+		return nil, fmt.Errorf("Error while submitting the Vm read request: %w", err)
 	}
 
-	return CloudError{
-		httpRes: httpRes,
-		cloudError: err,
-	}
+	return response.GetVms(), nil
 }
@@ -2,24 +2,101 @@ package outscale
 
 import (
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/url"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
 
 func TestThrottling(t *testing.T) {
 	tags := map[error]bool{
-		nil:            false,
-		fmt.Errorf("not an cloudError"):       false,
-		CloudError{httpRes: &http.Response{StatusCode: 500}}:       false,
-		CloudError{httpRes: nil, cloudError: fmt.Errorf("Hello Error")}:       false,
-		CloudError{httpRes: &http.Response{StatusCode: ThrottlingErrors[0]}}:       true,
-		CloudError{httpRes: &http.Response{StatusCode: ThrottlingErrors[1]}}:       true,
+		nil:                             false,
+		fmt.Errorf("not an cloudError"): false,
+		CloudError{httpRes: &http.Response{StatusCode: 500}}:                 false,
+		CloudError{httpRes: nil, cloudError: fmt.Errorf("Hello Error")}:      false,
+		CloudError{httpRes: &http.Response{StatusCode: ThrottlingErrors[0]}}: true,
+		CloudError{httpRes: &http.Response{StatusCode: ThrottlingErrors[1]}}: true,
 	}
 	for err, expected := range tags {
-		res := isThrottlingError(err)
+		res := defaultRetryPolicy().isRetryable(err)
 
 		assert.Equalf(t, expected, res, "The result is not the one expected for the error '%v'", err)
 	}
 }
+
+func TestRetryableStatusCodes(t *testing.T) {
+	policy := retryPolicy{statusCodes: []int{500}}
+
+	assert.True(t, policy.isRetryable(CloudError{httpRes: &http.Response{StatusCode: 500}}))
+	assert.False(t, policy.isRetryable(CloudError{httpRes: &http.Response{StatusCode: 429}}))
+}
+
+func TestRetryableNetworkErrors(t *testing.T) {
+	errs := map[error]bool{
+		&url.Error{Op: "Post", URL: "https://api/api/v1/ReadVms", Err: syscall.ECONNRESET}:                           true,
+		&url.Error{Op: "Post", URL: "https://api/api/v1/DeleteVms", Err: io.EOF}:                                     true,
+		&url.Error{Op: "Post", URL: "https://api/api/v1/ReadVms", Err: &net.DNSError{IsTimeout: true}}:               true,
+		&url.Error{Op: "Post", URL: "https://api/api/v1/ReadVms", Err: &net.DNSError{Err: "no such host"}}:           false,
+		&url.Error{Op: "Post", URL: "https://api/api/v1/ReadVms", Err: fmt.Errorf("x509: certificate is not valid")}: false,
+		// The VM may have been created, a retry would create another one
+		&url.Error{Op: "Post", URL: "https://api/api/v1/CreateVms", Err: io.EOF}:                         false,
+		&url.Error{Op: "Post", URL: "https://api/api/v1/CreatePublicIp", Err: syscall.ECONNRESET}:        false,
+		&url.Error{Op: "Post", URL: "https://api/api/v1/CreateVms", Err: &net.DNSError{IsTimeout: true}}: false,
+	}
+	for err, expected := range errs {
+		res := defaultRetryPolicy().isRetryable(CloudError{cloudError: err})
+
+		assert.Equalf(t, expected, res, "The result is not the one expected for the error '%v'", err)
+	}
+}
+
+func TestRetryAfterDelay(t *testing.T) {
+	withHeader := func(value string) error {
+		header := http.Header{}
+		header.Set("Retry-After", value)
+		return CloudError{httpRes: &http.Response{StatusCode: 429, Header: header}}
+	}
+
+	delay, ok := retryAfterDelay(withHeader("7"))
+	assert.True(t, ok)
+	assert.Equal(t, 7*time.Second, delay)
+
+	delay, ok = retryAfterDelay(withHeader(time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)))
+	assert.True(t, ok)
+	assert.InDelta(t, time.Minute, delay, float64(5*time.Second))
+
+	_, ok = retryAfterDelay(withHeader("soon"))
+	assert.False(t, ok)
+
+	_, ok = retryAfterDelay(CloudError{httpRes: &http.Response{StatusCode: 429, Header: http.Header{}}})
+	assert.False(t, ok)
+
+	_, ok = retryAfterDelay(CloudError{cloudError: io.EOF})
+	assert.False(t, ok)
+}
+
+func TestRetryAfterMaxDelay(t *testing.T) {
+	withHeader := func(value string) error {
+		header := http.Header{}
+		header.Set("Retry-After", value)
+		return CloudError{httpRes: &http.Response{StatusCode: 429, Header: header}}
+	}
+
+	policy := retryPolicy{maxDelay: 15 * time.Second}
+	assert.Equal(t, 7*time.Second, policy.retryAfterOrRandomDelay(1, withHeader("7"), nil))
+	assert.Equal(t, 15*time.Second, policy.retryAfterOrRandomDelay(1, withHeader("3600"), nil))
+}
+
+func TestIgnoreNotFound(t *testing.T) {
+	notFound := CloudError{httpRes: &http.Response{StatusCode: 404}}
+	failure := CloudError{httpRes: &http.Response{StatusCode: 500}}
+
+	assert.NoError(t, ignoreNotFound(nil, "VM", "i-12345678"))
+	assert.NoError(t, ignoreNotFound(fmt.Errorf("Error while submitting the DeleteVm request: %w", notFound), "VM", "i-12345678"))
+	assert.Error(t, ignoreNotFound(fmt.Errorf("Error while submitting the DeleteVm request: %w", failure), "VM", "i-12345678"))
+}
@@ -0,0 +1,174 @@
+package outscale
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	retry "github.com/avast/retry-go"
+	osc "github.com/outscale/osc-sdk-go/v2"
+)
+
+func readVmTypes(d *OscDriver, filters osc.FiltersVmType) ([]osc.VmType, error) {
+	oscApi, err := d.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	request := osc.ReadVmTypesRequest{
+		Filters: &filters,
+	}
+
+	var httpRes *http.Response
+	var response osc.ReadVmTypesResponse
+	err = retry.Do(
+		func() error {
+			var response_error error
+			response, httpRes, response_error = oscApi.client.VmApi.ReadVmTypes(oscApi.context).ReadVmTypesRequest(request).Execute()
+			return wrapError(response_error, httpRes)
+		},
+		oscApi.retryOptions...,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error while submitting the VmType read request: %w", err)
+	}
+
+	return response.GetVmTypes(), nil
+}
+
+// tinaVmTypePattern matches the Outscale VM types tinavW.cXrYpZ where W is
+// the generation, X the number of vCores, Y the memory in GiB and Z the
+// performance
+var tinaVmTypePattern = regexp.MustCompile(`^tinav([0-9]+)\.c([0-9]+)r([0-9]+)p([0-9]+)$`)
+
+const (
+	maxVmTypeSuggestions = 3
+)
+
+// validateVmType checks that the VM type is available in the region. The
+// tina types are built on demand, so only their generation must exist, while
+// the AWS-style types (e.g. t2.small) must be listed by the API.
+func validateVmType(d *OscDriver, vmType string) error {
+	vmTypes, err := readVmTypes(d, osc.FiltersVmType{})
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(vmTypes))
+	for _, availableType := range vmTypes {
+		names = append(names, availableType.GetVmTypeName())
+	}
+
+	return checkVmType(vmType, names, d.Region)
+}
+
+func checkVmType(vmType string, availableTypes []string, region string) error {
+	for _, name := range availableTypes {
+		if name == vmType {
+			return nil
+		}
+	}
+
+	if match := tinaVmTypePattern.FindStringSubmatch(vmType); match != nil {
+		generation, _ := strconv.Atoi(match[1])
+		cores, _ := strconv.Atoi(match[2])
+		memory, _ := strconv.Atoi(match[3])
+		performance, _ := strconv.Atoi(match[4])
+
+		if cores < 1 || memory < 1 {
+			return fmt.Errorf("The VM type '%v' is not valid: the number of vCores and the memory must be > 0", vmType)
+		}
+
+		if performance < 1 || performance > 3 {
+			return fmt.Errorf("The VM type '%v' is not valid: the performance must be between 1 and 3", vmType)
+		}
+
+		generations := tinaGenerations(availableTypes)
+		if len(generations) == 0 || generations[generation] {
+			return nil
+		}
+
+		return fmt.Errorf("The VM type '%v' is not available in the region '%v': the generation v%v does not exist", vmType, region, generation)
+	}
+
+	message := fmt.Sprintf("The VM type '%v' is not available in the region '%v'", vmType, region)
+	if suggestions := closestVmTypes(vmType, availableTypes, maxVmTypeSuggestions); len(suggestions) > 0 {
+		message = fmt.Sprintf("%s, did you mean %s?", message, strings.Join(suggestions, ", "))
+	}
+
+	return errors.New(message)
+}
+
+// tinaGenerations returns the generations of the tina types listed by the API
+func tinaGenerations(availableTypes []string) map[int]bool {
+	generations := make(map[int]bool)
+	for _, name := range availableTypes {
+		if match := tinaVmTypePattern.FindStringSubmatch(name); match != nil {
+			generation, _ := strconv.Atoi(match[1])
+			generations[generation] = true
+		}
+	}
+	return generations
+}
+
+// closestVmTypes returns the names nearest to the VM type by edit distance
+func closestVmTypes(vmType string, availableTypes []string, count int) []string {
+	candidates := make([]string, len(availableTypes))
+	copy(candidates, availableTypes)
+
+	distances := make(map[string]int, len(candidates))
+	for _, name := range candidates {
+		distances[name] = levenshteinDistance(vmType, name)
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if distances[candidates[i]] != distances[candidates[j]] {
+			return distances[candidates[i]] < distances[candidates[j]]
+		}
+		return candidates[i] < candidates[j]
+	})
+
+	if len(candidates) > count {
+		candidates = candidates[:count]
+	}
+
+	suggestions := make([]string, 0, len(candidates))
+	for _, name := range candidates {
+		suggestions = append(suggestions, fmt.Sprintf("'%v'", name))
+	}
+	return suggestions
+}
+
+func levenshteinDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = previous[j-1] + cost
+			if previous[j]+1 < current[j] {
+				current[j] = previous[j] + 1
+			}
+			if current[j-1]+1 < current[j] {
+				current[j] = current[j-1] + 1
+			}
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
@@ -0,0 +1,38 @@
+package outscale
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckVmType(t *testing.T) {
+	availableTypes := []string{"tinav5.c1r1p2", "tinav6.c2r4p2", "t2.small", "t2.medium", "m4.large"}
+
+	cases := map[string]string{
+		"t2.small":       "",
+		"tinav5.c1r1p2":  "",
+		"tinav5.c4r16p1": "",
+		"tinav6.c2r4p3":  "",
+		"tinav4.c2r4p2":  "The VM type 'tinav4.c2r4p2' is not available in the region 'eu-west-2': the generation v4 does not exist",
+		"tinav5.c0r4p2":  "The VM type 'tinav5.c0r4p2' is not valid: the number of vCores and the memory must be > 0",
+		"tinav5.c2r4p4":  "The VM type 'tinav5.c2r4p4' is not valid: the performance must be between 1 and 3",
+		"t2.smal":        "The VM type 't2.smal' is not available in the region 'eu-west-2', did you mean 't2.small', 't2.medium', 'm4.large'?",
+	}
+
+	for vmType, expected := range cases {
+		err := checkVmType(vmType, availableTypes, "eu-west-2")
+		if expected == "" {
+			assert.NoErrorf(t, err, "The VM type '%v' should be accepted", vmType)
+		} else if assert.Errorf(t, err, "The VM type '%v' should be rejected", vmType) {
+			assert.Equal(t, expected, err.Error())
+		}
+	}
+}
+
+func TestLevenshteinDistance(t *testing.T) {
+	assert.Equal(t, 0, levenshteinDistance("t2.small", "t2.small"))
+	assert.Equal(t, 1, levenshteinDistance("t2.smal", "t2.small"))
+	assert.Equal(t, 3, levenshteinDistance("kitten", "sitting"))
+	assert.Equal(t, 8, levenshteinDistance("", "t2.small"))
+}
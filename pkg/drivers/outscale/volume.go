@@ -0,0 +1,201 @@
+package outscale
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	retry "github.com/avast/retry-go"
+	"github.com/docker/machine/libmachine/log"
+	osc "github.com/outscale/osc-sdk-go/v2"
+)
+
+func readVolumes(d *OscDriver, filters osc.FiltersVolume) ([]osc.Volume, error) {
+	oscApi, err := d.getClient()
+	if err != nil {
+		return nil, err
+	}
+
+	request := osc.ReadVolumesRequest{
+		Filters: &filters,
+	}
+
+	var httpRes *http.Response
+	var response osc.ReadVolumesResponse
+	err = retry.Do(
+		func() error {
+			var response_error error
+			response, httpRes, response_error = oscApi.client.VolumeApi.ReadVolumes(oscApi.context).ReadVolumesRequest(request).Execute()
+			return wrapError(response_error, httpRes)
+		},
+		oscApi.retryOptions...,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error while submitting the Volume read request: %w", err)
+	}
+
+	return response.GetVolumes(), nil
+}
+
+func deleteVolume(d *OscDriver, volumeId string) error {
+	log.Debug("Deletion of the Volume")
+
+	// Get the client
+	oscApi, err := d.getClient()
+	if err != nil {
+		return err
+	}
+
+	request := osc.DeleteVolumeRequest{
+		VolumeId: volumeId,
+	}
+
+	// The Volume stays in use until it is detached from the VM
+	var httpRes *http.Response
+	err = retryWhileInUse(func() error {
+		return retry.Do(
+			func() error {
+				var response_error error
+				_, httpRes, response_error = oscApi.client.VolumeApi.DeleteVolume(oscApi.context).DeleteVolumeRequest(request).Execute()
+				return wrapError(response_error, httpRes)
+			},
+			oscApi.retryOptions...,
+		)
+	})
+
+	if err != nil {
+		return fmt.Errorf("Error while submitting the Volume deletion request: %w", err)
+	}
+
+	return nil
+}
+
+func updateVolumeSize(d *OscDriver, volumeId string, size int32) error {
+	log.Debugf("Resizing the Volume '%v' to %v GiB", volumeId, size)
+
+	// Get the client
+	oscApi, err := d.getClient()
+	if err != nil {
+		return err
+	}
+
+	request := osc.UpdateVolumeRequest{
+		VolumeId: volumeId,
+	}
+	request.SetSize(size)
+
+	var httpRes *http.Response
+	err = retry.Do(
+		func() error {
+			var response_error error
+			_, httpRes, response_error = oscApi.client.VolumeApi.UpdateVolume(oscApi.context).UpdateVolumeRequest(request).Execute()
+			return wrapError(response_error, httpRes)
+		},
+		oscApi.retryOptions...,
+	)
+
+	if err != nil {
+		return fmt.Errorf("Error while submitting the Volume update request: %w", err)
+	}
+
+	return waitForVolumeSize(d, volumeId, size)
+}
+
+func waitForVolumeSize(d *OscDriver, volumeId string, size int32) error {
+	return retry.Do(
+		func() error {
+			volumes, err := readVolumes(d, osc.FiltersVolume{
+				VolumeIds: &[]string{volumeId},
+			})
+			if err != nil {
+				return err
+			}
+
+			if len(volumes) == 0 {
+				return fmt.Errorf("The Volume '%v' has not been found", volumeId)
+			}
+
+			if volumes[0].GetSize() != size {
+				return errors.New("The Volume is not (yet) resized")
+			}
+			return nil
+		},
+		retry.Attempts(defaultReadMaxAttempts),
+		retry.Delay(defaultReadDelay),
+		retry.DelayType(retry.FixedDelay),
+		retry.OnRetry(func(n uint, err error) {
+			log.Debug("Volume is not resized, retrying...")
+		}),
+	)
+}
+
+// rootVolumeId returns the id of the volume mounted as the root device of the VM
+func rootVolumeId(vm osc.Vm) (string, error) {
+	for _, blockDevice := range vm.GetBlockDeviceMappings() {
+		if blockDevice.GetDeviceName() == vm.GetRootDeviceName() {
+			return blockDevice.Bsu.GetVolumeId(), nil
+		}
+	}
+	return "", fmt.Errorf("The root volume of the VM '%v' has not been found", vm.GetVmId())
+}
+
+// validateVolumeSize checks the new size of a volume against the limits of its
+// type. The volumes of a type unknown to the driver are left to the API.
+func (d *OscDriver) validateVolumeSize(volume osc.Volume, size int32) error {
+	limits, ok := d.volumeTypes[volume.GetVolumeType()]
+	if !ok {
+		return nil
+	}
+
+	iops := int32(0)
+	if limits.maxIops > 0 {
+		iops = volume.GetIops()
+	}
+	return d.volumeTypes.validate(volume.GetVolumeType(), size, iops)
+}
+
+// volumeIdOfDevice returns the id of the volume attached to the VM as deviceName
+func volumeIdOfDevice(vm osc.Vm, deviceName string) (string, error) {
+	for _, blockDevice := range vm.GetBlockDeviceMappings() {
+		if blockDevice.GetDeviceName() == deviceName {
+			return blockDevice.Bsu.GetVolumeId(), nil
+		}
+	}
+	return "", fmt.Errorf("No volume is attached to the VM '%v' as '%v'", vm.GetVmId(), deviceName)
+}
+
+// tagVolumes adds the machine tags to the volumes of the VM
+func tagVolumes(d *OscDriver, vm osc.Vm) error {
+	for _, mapping := range vm.GetBlockDeviceMappings() {
+		volumeId := mapping.Bsu.GetVolumeId()
+		if volumeId == "" {
+			continue
+		}
+		if err := addMachineTag(d, volumeId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// logRootVolumePerformance reports the IOPS of the root volume of the VM as
+// seen by the API. It only logs, a failure does not stop the creation.
+func (d *OscDriver) logRootVolumePerformance(vm osc.Vm) {
+	volumeId, err := rootVolumeId(vm)
+	if err != nil {
+		log.Debugf("Unable to report the performance of the root volume: %v", err)
+		return
+	}
+
+	volumes, err := readVolumes(d, osc.FiltersVolume{
+		VolumeIds: &[]string{volumeId},
+	})
+	if err != nil || len(volumes) == 0 {
+		log.Debugf("Unable to read the root volume '%v': %v", volumeId, err)
+		return
+	}
+
+	volume := volumes[0]
+	log.Debugf("The root volume '%v' (%v, %v GiB) has %v", volumeId, volume.GetVolumeType(), volume.GetSize(), d.volumeTypes.volumePerformance(volume))
+}
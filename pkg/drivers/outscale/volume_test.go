@@ -0,0 +1,44 @@
+package outscale
+
+import (
+	"testing"
+
+	osc "github.com/outscale/osc-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRootVolumeId(t *testing.T) {
+	vm := osc.Vm{
+		VmId:           osc.PtrString("i-12345678"),
+		RootDeviceName: osc.PtrString("/dev/sda1"),
+		BlockDeviceMappings: &[]osc.BlockDeviceMappingCreated{
+			{DeviceName: osc.PtrString("/dev/xvdb"), Bsu: &osc.BsuCreated{VolumeId: osc.PtrString("vol-data")}},
+			{DeviceName: osc.PtrString("/dev/sda1"), Bsu: &osc.BsuCreated{VolumeId: osc.PtrString("vol-root")}},
+		},
+	}
+
+	volumeId, err := rootVolumeId(vm)
+	assert.NoError(t, err)
+	assert.Equal(t, "vol-root", volumeId)
+
+	vm.RootDeviceName = osc.PtrString("/dev/sdz")
+	_, err = rootVolumeId(vm)
+	assert.Error(t, err)
+}
+
+func TestVolumeIdOfDevice(t *testing.T) {
+	vm := osc.Vm{
+		VmId: osc.PtrString("i-12345678"),
+		BlockDeviceMappings: &[]osc.BlockDeviceMappingCreated{
+			{DeviceName: osc.PtrString("/dev/xvdb"), Bsu: &osc.BsuCreated{VolumeId: osc.PtrString("vol-data")}},
+			{DeviceName: osc.PtrString("/dev/sda1"), Bsu: &osc.BsuCreated{VolumeId: osc.PtrString("vol-root")}},
+		},
+	}
+
+	volumeId, err := volumeIdOfDevice(vm, "/dev/xvdb")
+	assert.NoError(t, err)
+	assert.Equal(t, "vol-data", volumeId)
+
+	_, err = volumeIdOfDevice(vm, "/dev/xvdc")
+	assert.EqualError(t, err, "No volume is attached to the VM 'i-12345678' as '/dev/xvdc'")
+}
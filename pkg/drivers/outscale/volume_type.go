@@ -0,0 +1,210 @@
+package outscale
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	osc "github.com/outscale/osc-sdk-go/v2"
+)
+
+// volumeTypeLimits are the bounds enforced by the API for a volume type. The
+// IOPS can only be provisioned when maxIops is set. The baseline describes the
+// IOPS granted according to the size, when they are not provisioned.
+type volumeTypeLimits struct {
+	minSize            int32
+	maxSize            int32
+	minIops            int32
+	maxIops            int32
+	maxIopsPerGiB      int32
+	baselineIopsPerGiB int32
+	minBaselineIops    int32
+	maxBaselineIops    int32
+}
+
+// volumeTypeCatalog lists the accepted volume types by name
+type volumeTypeCatalog map[string]volumeTypeLimits
+
+var defaultVolumeTypes = volumeTypeCatalog{
+	"standard": {minSize: 1, maxSize: 14901},
+	"gp2":      {minSize: 1, maxSize: 14901, baselineIopsPerGiB: 3, minBaselineIops: 100, maxBaselineIops: 7500},
+	"io1":      {minSize: 4, maxSize: 14901, minIops: 1, maxIops: 13000, maxIopsPerGiB: 300},
+}
+
+// volumeTypesWith returns the default volume types completed, or overridden,
+// by the types given as
+// name=<type>,size=<min>-<max>,iops=<min>-<max>,iops-per-gib=<n>,baseline-iops-per-gib=<n>,baseline-iops=<min>-<max>
+func volumeTypesWith(specs []string) (volumeTypeCatalog, error) {
+	volumeTypes := make(volumeTypeCatalog, len(defaultVolumeTypes)+len(specs))
+	for name, limits := range defaultVolumeTypes {
+		volumeTypes[name] = limits
+	}
+
+	for _, spec := range specs {
+		name, limits, err := parseVolumeTypeSpec(spec)
+		if err != nil {
+			return nil, fmt.Errorf("the volume type '%v' is not valid: %v", spec, err)
+		}
+		volumeTypes[name] = limits
+	}
+
+	return volumeTypes, nil
+}
+
+func parseVolumeTypeSpec(spec string) (string, volumeTypeLimits, error) {
+	name := ""
+	limits := volumeTypeLimits{}
+
+	for _, field := range strings.Split(spec, ",") {
+		splittedField := strings.SplitN(field, "=", 2)
+		if len(splittedField) != 2 {
+			return name, limits, fmt.Errorf("the field '%v' does not have the syntax 'key=value'", field)
+		}
+		key := strings.TrimSpace(splittedField[0])
+		value := strings.TrimSpace(splittedField[1])
+
+		var err error
+		switch key {
+		case "name":
+			name = value
+		case "size":
+			limits.minSize, limits.maxSize, err = parseRange(value)
+		case "iops":
+			limits.minIops, limits.maxIops, err = parseRange(value)
+		case "iops-per-gib":
+			limits.maxIopsPerGiB, err = parsePositiveInt32(value)
+		case "baseline-iops-per-gib":
+			limits.baselineIopsPerGiB, err = parsePositiveInt32(value)
+		case "baseline-iops":
+			limits.minBaselineIops, limits.maxBaselineIops, err = parseRange(value)
+		default:
+			err = fmt.Errorf("the key '%v' is unknown (expected: 'name'|'size'|'iops'|'iops-per-gib'|'baseline-iops-per-gib'|'baseline-iops')", key)
+		}
+		if err != nil {
+			return name, limits, err
+		}
+	}
+
+	if name == "" {
+		return name, limits, fmt.Errorf("the name is required")
+	}
+
+	if limits.maxSize == 0 {
+		return name, limits, fmt.Errorf("the size range is required")
+	}
+
+	if limits.maxIops > 0 && limits.maxIopsPerGiB == 0 {
+		limits.maxIopsPerGiB = limits.maxIops
+	}
+
+	return name, limits, nil
+}
+
+// parseRange parses <min>-<max>
+func parseRange(value string) (int32, int32, error) {
+	splittedValue := strings.SplitN(value, "-", 2)
+	if len(splittedValue) != 2 {
+		return 0, 0, fmt.Errorf("'%v' does not have the syntax '<min>-<max>'", value)
+	}
+
+	min, err := parsePositiveInt32(splittedValue[0])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	max, err := parsePositiveInt32(splittedValue[1])
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if min > max {
+		return 0, 0, fmt.Errorf("the range '%v' is empty", value)
+	}
+
+	return min, max, nil
+}
+
+func parsePositiveInt32(value string) (int32, error) {
+	number, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || number <= 0 {
+		return 0, fmt.Errorf("'%v' is not a number > 0", value)
+	}
+	return int32(number), nil
+}
+
+// supported returns the accepted volume types, for the error messages
+func (c volumeTypeCatalog) supported() string {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, fmt.Sprintf("'%s'", name))
+	}
+	sort.Strings(names)
+	return strings.Join(names, "|")
+}
+
+// iopsLimits describes the IOPS limits of the types with provisioned IOPS,
+// for the help and the error messages
+func (c volumeTypeCatalog) iopsLimits() string {
+	var descriptions []string
+	for name, limits := range c {
+		if limits.maxIops > 0 {
+			descriptions = append(descriptions, fmt.Sprintf("%s: between %v and %v, at most %v per GiB", name, limits.minIops, limits.maxIops, limits.maxIopsPerGiB))
+		}
+	}
+	sort.Strings(descriptions)
+	return strings.Join(descriptions, "; ")
+}
+
+// validate checks a volume against the limits of its type. A size of 0 stands
+// for the size of the snapshot, unknown here, and an iops of 0 for no
+// provisioned IOPS.
+func (c volumeTypeCatalog) validate(diskType string, size int32, iops int32) error {
+	limits, ok := c[diskType]
+	if !ok {
+		return fmt.Errorf("the type '%v' is not accepted (expected: %v)", diskType, c.supported())
+	}
+
+	if size != 0 && (size < limits.minSize || size > limits.maxSize) {
+		return fmt.Errorf("the size (%v GiB) of a '%v' volume is not accepted, it must be between %v and %v", size, diskType, limits.minSize, limits.maxSize)
+	}
+
+	if limits.maxIops == 0 {
+		if iops != 0 {
+			return fmt.Errorf("the iops can not be set for a '%v' volume", diskType)
+		}
+		return nil
+	}
+
+	if iops < limits.minIops || iops > limits.maxIops {
+		return fmt.Errorf("the iops (%v) of a '%v' volume is not accepted, it must be between %v and %v", iops, diskType, limits.minIops, limits.maxIops)
+	}
+
+	if size != 0 && iops > size*limits.maxIopsPerGiB {
+		return fmt.Errorf("the iops (%v) of a '%v' volume is not accepted, it must be at most %v per GiB (%v for %v GiB)", iops, diskType, limits.maxIopsPerGiB, size*limits.maxIopsPerGiB, size)
+	}
+
+	return nil
+}
+
+// volumePerformance describes the IOPS of a volume: the provisioned ones or
+// the baseline of its type
+func (c volumeTypeCatalog) volumePerformance(volume osc.Volume) string {
+	limits := c[volume.GetVolumeType()]
+
+	switch {
+	case limits.maxIops > 0:
+		return fmt.Sprintf("%v provisioned IOPS", volume.GetIops())
+	case limits.baselineIopsPerGiB > 0:
+		baseline := volume.GetSize() * limits.baselineIopsPerGiB
+		if baseline < limits.minBaselineIops {
+			baseline = limits.minBaselineIops
+		}
+		if limits.maxBaselineIops > 0 && baseline > limits.maxBaselineIops {
+			baseline = limits.maxBaselineIops
+		}
+		return fmt.Sprintf("a baseline of %v IOPS", baseline)
+	default:
+		return "no guaranteed IOPS"
+	}
+}
@@ -0,0 +1,67 @@
+package outscale
+
+import (
+	"testing"
+
+	osc "github.com/outscale/osc-sdk-go/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVolumeTypesWith(t *testing.T) {
+	volumeTypes, err := volumeTypesWith([]string{
+		"name=gp3,size=1-16384,iops=3000-16000,iops-per-gib=500",
+		"name=gp2,size=1-16384,baseline-iops-per-gib=3,baseline-iops=100-16000",
+		"name=cold,size=125-16384",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, volumeTypeLimits{minSize: 1, maxSize: 16384, minIops: 3000, maxIops: 16000, maxIopsPerGiB: 500}, volumeTypes["gp3"])
+	assert.Equal(t, int32(16384), volumeTypes["gp2"].maxSize)
+	assert.Equal(t, defaultVolumeTypes["io1"], volumeTypes["io1"])
+	assert.Equal(t, "'cold'|'gp2'|'gp3'|'io1'|'standard'", volumeTypes.supported())
+
+	// The defaults are not modified
+	assert.Equal(t, int32(14901), defaultVolumeTypes["gp2"].maxSize)
+	assert.NotContains(t, defaultVolumeTypes, "gp3")
+
+	assert.NoError(t, volumeTypes.validate("gp3", 10, 5000))
+	assert.EqualError(t, volumeTypes.validate("gp3", 10, 6000), "the iops (6000) of a 'gp3' volume is not accepted, it must be at most 500 per GiB (5000 for 10 GiB)")
+	assert.EqualError(t, volumeTypes.validate("cold", 100, 0), "the size (100 GiB) of a 'cold' volume is not accepted, it must be between 125 and 16384")
+
+	errors := map[string]string{
+		"size=1-10":                "the volume type 'size=1-10' is not valid: the name is required",
+		"name=sc1":                 "the volume type 'name=sc1' is not valid: the size range is required",
+		"name=sc1,size=10":         "the volume type 'name=sc1,size=10' is not valid: '10' does not have the syntax '<min>-<max>'",
+		"name=sc1,size=10-1":       "the volume type 'name=sc1,size=10-1' is not valid: the range '10-1' is empty",
+		"name=sc1,size=0-10":       "the volume type 'name=sc1,size=0-10' is not valid: '0' is not a number > 0",
+		"name=sc1,size=1-10,burst": "the volume type 'name=sc1,size=1-10,burst' is not valid: the field 'burst' does not have the syntax 'key=value'",
+		"name=sc1,size=1-10,mb=25": "the volume type 'name=sc1,size=1-10,mb=25' is not valid: the key 'mb' is unknown (expected: 'name'|'size'|'iops'|'iops-per-gib'|'baseline-iops-per-gib'|'baseline-iops')",
+	}
+	for spec, expected := range errors {
+		_, err := volumeTypesWith([]string{spec})
+		assert.EqualError(t, err, expected, spec)
+	}
+}
+
+func TestIopsLimits(t *testing.T) {
+	assert.Equal(t, "io1: between 1 and 13000, at most 300 per GiB", defaultVolumeTypes.iopsLimits())
+
+	volumeTypes, err := volumeTypesWith([]string{"name=io2,size=4-16384,iops=100-64000,iops-per-gib=500"})
+	assert.NoError(t, err)
+	assert.Equal(t, "io1: between 1 and 13000, at most 300 per GiB; io2: between 100 and 64000, at most 500 per GiB", volumeTypes.iopsLimits())
+}
+
+func TestVolumePerformance(t *testing.T) {
+	volume := func(volumeType string, size int32, iops int32) osc.Volume {
+		return osc.Volume{
+			VolumeType: osc.PtrString(volumeType),
+			Size:       osc.PtrInt32(size),
+			Iops:       osc.PtrInt32(iops),
+		}
+	}
+
+	assert.Equal(t, "3000 provisioned IOPS", defaultVolumeTypes.volumePerformance(volume("io1", 20, 3000)))
+	assert.Equal(t, "a baseline of 100 IOPS", defaultVolumeTypes.volumePerformance(volume("gp2", 15, 0)))
+	assert.Equal(t, "a baseline of 300 IOPS", defaultVolumeTypes.volumePerformance(volume("gp2", 100, 0)))
+	assert.Equal(t, "a baseline of 7500 IOPS", defaultVolumeTypes.volumePerformance(volume("gp2", 5000, 0)))
+	assert.Equal(t, "no guaranteed IOPS", defaultVolumeTypes.volumePerformance(volume("standard", 15, 0)))
+}
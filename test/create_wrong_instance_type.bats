@@ -11,4 +11,5 @@ require_env OSC_SECRET_KEY
     run machine create -d outscale --outscale-instance-type toto $NAME 
     echo ${output}
     [ "$status" -eq 1 ]
+    [[ ${output} == *"The VM type 'toto' is not available"* ]]
 }
\ No newline at end of file